@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errNoBrowser is returned when there is nothing to open URLs with, for
+// example over SSH
+var errNoBrowser = errors.New("no browser available")
+
+// resolveBrowser returns the browser command to use: --browser if it was
+// passed, otherwise the first usable entry of the colon separated
+// $BROWSER. It is empty if neither is set.
+func resolveBrowser(browser string) string {
+	if len(browser) > 0 {
+		return browser
+	}
+
+	entries := filepath.SplitList(os.Getenv("BROWSER"))
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(fields[0]); err == nil {
+			return entry
+		}
+	}
+
+	if len(entries) > 0 {
+		return entries[0]
+	}
+	return ""
+}
+
+// isHeadless reports whether there is no display to open a browser on,
+// like in an SSH session without X forwarding
+func isHeadless(goos string, getenv func(string) string) bool {
+	display := len(getenv("DISPLAY")) > 0 || len(getenv("WAYLAND_DISPLAY")) > 0
+
+	for _, env := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if len(getenv(env)) > 0 {
+			return !display
+		}
+	}
+
+	switch goos {
+	case "darwin", "windows":
+		return false
+	default:
+		return !display
+	}
+}
+
+// multiURLLaunchers open the default browser on Linux and BSDs and,
+// unlike xdg-open, pass on all the URLs they are given
+var multiURLLaunchers = []string{"x-www-browser", "sensible-browser"}
+
+// singleURLLaunchers hand a single URL to the default browser, so they
+// are run once per URL even when passed as the browser
+var singleURLLaunchers = []string{"xdg-open", "wslview", "gio", "cmd"}
+
+// launchers exit once the browser has the URLs, so they are waited for
+// to report failures. sensible-browser can pick a terminal browser.
+var launchers = append([]string{"open", "sensible-browser"}, singleURLLaunchers...)
+
+// terminalBrowsers run in the terminal, so they need it and have to be
+// waited for. Other browsers are left running in the background.
+var terminalBrowsers = []string{"w3m", "lynx", "links", "elinks", "browsh", "carbonyl", "www-browser"}
+
+// browserCommands returns the commands opening the URLs. Browsers which
+// take several URLs get them in a single invocation so that a new
+// instance isn't started for each of them. A %s in the browser command
+// is replaced by the URL, which means one invocation per URL, as do
+// launchers like xdg-open which only take one.
+func browserCommands(browser, goos string, urls []string, lookPath func(string) (string, error)) ([][]string, error) {
+	commands := [][]string{}
+
+	if len(browser) > 0 {
+		fields := strings.Fields(browser)
+		placeholder := strings.Contains(browser, "%s")
+		if !placeholder && !slices.Contains(singleURLLaunchers, filepath.Base(fields[0])) {
+			return append(commands, append(fields, urls...)), nil
+		}
+
+		for _, url := range urls {
+			command := []string{}
+			for _, f := range fields {
+				command = append(command, strings.ReplaceAll(f, "%s", url))
+			}
+			if !placeholder {
+				command = append(command, url)
+			}
+			commands = append(commands, command)
+		}
+		return commands, nil
+	}
+
+	switch goos {
+	case "darwin":
+		commands = append(commands, append([]string{"open"}, urls...))
+	case "windows":
+		for _, url := range urls {
+			commands = append(commands, []string{"cmd", "/c", "start", url})
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		for _, launcher := range multiURLLaunchers {
+			if _, err := lookPath(launcher); err == nil {
+				return append(commands, append([]string{launcher}, urls...)), nil
+			}
+		}
+
+		// xdg-open only takes a single URL
+		for _, url := range urls {
+			commands = append(commands, []string{"xdg-open", url})
+		}
+	default:
+		return nil, errNoBrowser
+	}
+
+	return commands, nil
+}
+
+// openBrowser opens the URLs with the given browser command, $BROWSER
+// or the default opening command of the platform. Without a browser
+// set it returns errNoBrowser on headless systems instead of trying.
+func openBrowser(urls []string, browser string) error {
+	browser = resolveBrowser(browser)
+	if len(browser) == 0 && isHeadless(runtime.GOOS, os.Getenv) {
+		return errNoBrowser
+	}
+
+	commands, err := browserCommands(browser, runtime.GOOS, urls, exec.LookPath)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		fmt.Println("Opening", url)
+	}
+
+	for _, command := range commands {
+		cmd := exec.Command(command[0], command[1:]...)
+		if waitFor(command) {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			err = cmd.Run()
+		} else {
+			// GUI browsers keep running until they are closed
+			err = cmd.Start()
+			if err == nil {
+				err = cmd.Process.Release()
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("unable to open browser with %s: %v", command[0], err)
+		}
+	}
+
+	return nil
+}
+
+// waitFor reports whether the command is a launcher or a terminal
+// browser, which have to be waited for
+func waitFor(command []string) bool {
+	name := filepath.Base(command[0])
+	return slices.Contains(launchers, name) || slices.Contains(terminalBrowsers, name)
+}
+
+// printURLs is the fallback when there is no browser. On a terminal
+// the URLs are made clickable and copied to the clipboard as well.
+func printURLs(urls []string) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		for _, url := range urls {
+			fmt.Println(url)
+		}
+		return
+	}
+
+	for _, url := range urls {
+		fmt.Println(hyperlink(url, url))
+	}
+	fmt.Print(copyToClipboard(strings.Join(urls, "\n")))
+	fmt.Fprintln(os.Stderr, "No browser available, copied the URLs to the clipboard if the terminal supports it")
+}
+
+// copyToClipboard returns the OSC 52 sequence asking the terminal to
+// set the clipboard, which also works over SSH
+func copyToClipboard(text string) string {
+	return fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBrowserCommands(t *testing.T) {
+	urls := []string{"https://a", "https://b"}
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if slices.Contains(names, name) {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		}
+	}
+	noLaunchers := installed()
+
+	tests := []struct {
+		name     string
+		browser  string
+		goos     string
+		lookPath func(string) (string, error)
+		want     [][]string
+		err      error
+	}{
+		{"browser takes all urls", "firefox --new-tab", "linux", noLaunchers, [][]string{{"firefox", "--new-tab", "https://a", "https://b"}}, nil},
+		{"placeholder", "w3m %s", "linux", noLaunchers, [][]string{{"w3m", "https://a"}, {"w3m", "https://b"}}, nil},
+		{"macos", "", "darwin", noLaunchers, [][]string{{"open", "https://a", "https://b"}}, nil},
+		{"linux", "", "linux", noLaunchers, [][]string{{"xdg-open", "https://a"}, {"xdg-open", "https://b"}}, nil},
+		{"windows", "", "windows", noLaunchers, [][]string{{"cmd", "/c", "start", "https://a"}, {"cmd", "/c", "start", "https://b"}}, nil},
+		{"unknown platform", "", "plan9", noLaunchers, nil, errNoBrowser},
+		{"browser on unknown platform", "links", "plan9", noLaunchers, [][]string{{"links", "https://a", "https://b"}}, nil},
+		{"x-www-browser", "", "linux", installed("x-www-browser", "sensible-browser"), [][]string{{"x-www-browser", "https://a", "https://b"}}, nil},
+		{"sensible-browser", "", "freebsd", installed("sensible-browser"), [][]string{{"sensible-browser", "https://a", "https://b"}}, nil},
+		{"launchers only on linux", "", "darwin", installed("x-www-browser"), [][]string{{"open", "https://a", "https://b"}}, nil},
+		{"browser over launchers", "firefox", "linux", installed("x-www-browser"), [][]string{{"firefox", "https://a", "https://b"}}, nil},
+		{"single url launcher", "xdg-open", "linux", noLaunchers, [][]string{{"xdg-open", "https://a"}, {"xdg-open", "https://b"}}, nil},
+		{"single url launcher with args", "gio open", "linux", noLaunchers, [][]string{{"gio", "open", "https://a"}, {"gio", "open", "https://b"}}, nil},
+		{"single url launcher path", "/usr/bin/wslview", "linux", noLaunchers, [][]string{{"/usr/bin/wslview", "https://a"}, {"/usr/bin/wslview", "https://b"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := browserCommands(tt.browser, tt.goos, urls, tt.lookPath)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	tests := []struct {
+		command []string
+		want    bool
+	}{
+		{[]string{"w3m", "https://a"}, true},
+		{[]string{"/usr/local/bin/lynx", "https://a"}, true},
+		{[]string{"xdg-open", "https://a"}, true},
+		{[]string{"open", "https://a", "https://b"}, true},
+		{[]string{"cmd", "/c", "start", "https://a"}, true},
+		{[]string{"firefox", "https://a"}, false},
+		{[]string{"x-www-browser", "https://a"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := waitFor(tt.command); got != tt.want {
+			t.Errorf("waitFor(%v) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestIsHeadless(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{"linux with display", "linux", map[string]string{"DISPLAY": ":0"}, false},
+		{"linux with wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, false},
+		{"linux without display", "linux", map[string]string{}, true},
+		{"macos", "darwin", map[string]string{}, false},
+		{"ssh", "darwin", map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"}, true},
+		{"ssh on linux", "linux", map[string]string{"SSH_TTY": "/dev/pts/1"}, true},
+		{"forwarded x over ssh", "linux", map[string]string{"DISPLAY": "localhost:10.0", "SSH_TTY": "/dev/pts/1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isHeadless(tt.goos, func(k string) string { return tt.env[k] })
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBrowser(t *testing.T) {
+	t.Setenv("BROWSER", "chainlink-missing-browser:sh -c true")
+	if got := resolveBrowser(""); got != "sh -c true" {
+		t.Errorf("expected first usable $BROWSER entry, got %q", got)
+	}
+	if got := resolveBrowser("firefox"); got != "firefox" {
+		t.Errorf("expected --browser to win, got %q", got)
+	}
+
+	t.Setenv("BROWSER", "")
+	if got := resolveBrowser(""); got != "" {
+		t.Errorf("expected no browser, got %q", got)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/kong"
+)
+
+// localConfigName is the repo-local config file, looked up from the
+// current directory upwards
+const localConfigName = ".chainlink.toml"
+
+// Config holds the user's settings from the config files
+type Config struct {
+	// Templates are named --format templates
+	Templates map[string]string `toml:"templates"`
+
+	// Defaults are flag values used when a flag isn't passed. Flags for
+	// a single command go in a table named after it, e.g. [defaults.log].
+	Defaults map[string]any `toml:"defaults"`
+
+	// Presets are named sets of flags, selected with --preset
+	Presets map[string]map[string]any `toml:"presets"`
+
+	// Repos are named lists of org/repo which can be passed to --repo
+	Repos map[string][]string `toml:"repos"`
+
+	// Teams are named lists of users which can be passed to --author and
+	// --reviewer as @name
+	Teams map[string][]string `toml:"teams"`
+
+	// Hosts are settings for GitHub hosts other than github.com
+	Hosts map[string]HostConfig `toml:"hosts"`
+}
+
+// HostConfig configures how to talk to a GitHub host
+type HostConfig struct {
+	APIURL   string `toml:"api-url"`   // GraphQL endpoint
+	TokenEnv string `toml:"token-env"` // env var holding the token instead of CHAINLINK_TOKEN
+}
+
+// configPath returns $XDG_CONFIG_HOME/chainlink/config.toml, falling
+// back to ~/.config when XDG_CONFIG_HOME is not set.
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "chainlink", "config.toml")
+}
+
+// localConfigPath returns the closest .chainlink.toml in the current
+// directory or one of its parents, if there is one.
+func localConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, localConfigName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads the config file. A missing file is not an error and
+// results in an empty config.
+func loadConfig(path string) (Config, error) {
+	config := Config{}
+	if len(path) == 0 {
+		return config, nil
+	}
+
+	_, err := toml.DecodeFile(path, &config)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("unable to read config %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// localSafeFlags are the only flags .chainlink.toml can set. It comes
+// with the repo, which might not be trusted, so anything that runs
+// commands, reads or writes files or picks where the token is sent can
+// only be set in the global config.
+var localSafeFlags = []string{
+	// Filters
+	"author", "review-status", "min-approvals", "labels", "labels-mode", "reviewer",
+	"draft-status", "size", "mergeable", "merge-state", "checks",
+	"updated-since", "created-since", "updated-before", "created-before", "stale",
+	"title", "head", "base", "where", "chain-all", "chain-any", "min-depth", "max-depth",
+	"filter",
+
+	// Output
+	"output", "all", "format", "columns", "sort", "group-by", "print", "view", "compare", "only",
+	"cache-time",
+}
+
+// restrictLocalConfig drops the settings of .chainlink.toml which only
+// the global config may have, returning a description of each of them
+func restrictLocalConfig(c Config) (Config, []string) {
+	dropped := []string{}
+	if len(c.Hosts) > 0 {
+		dropped = append(dropped, "[hosts]")
+	}
+	if len(c.Repos) > 0 {
+		dropped = append(dropped, "[repos]")
+	}
+
+	// Per command tables of defaults are checked on their own
+	safeFlags := func(table string, flags map[string]any, commands bool) map[string]any {
+		safe := map[string]any{}
+		for name, v := range flags {
+			if _, isTable := v.(map[string]any); (commands && isTable) || slices.Contains(localSafeFlags, name) {
+				safe[name] = v
+			} else {
+				dropped = append(dropped, fmt.Sprintf("%s in [%s]", name, table))
+			}
+		}
+		return safe
+	}
+
+	defaults := safeFlags("defaults", c.Defaults, true)
+	for name, v := range defaults {
+		if table, ok := v.(map[string]any); ok {
+			defaults[name] = safeFlags("defaults."+name, table, false)
+		}
+	}
+
+	presets := map[string]map[string]any{}
+	for name, flags := range c.Presets {
+		presets[name] = safeFlags("presets."+name, flags, false)
+	}
+
+	sort.Strings(dropped)
+	return Config{
+		Templates: c.Templates,
+		Defaults:  defaults,
+		Presets:   presets,
+		Teams:     c.Teams,
+	}, dropped
+}
+
+// loadConfigs reads the global config followed by the repo-local one,
+// whose settings take precedence. Settings the local config isn't
+// allowed to have are ignored and returned as warnings.
+func loadConfigs(global, local string) (Config, []string, error) {
+	config, err := loadConfig(global)
+	if err != nil {
+		return config, nil, err
+	}
+
+	c, err := loadConfig(local)
+	if err != nil {
+		return config, nil, err
+	}
+
+	c, dropped := restrictLocalConfig(c)
+	warnings := []string{}
+	for _, d := range dropped {
+		warnings = append(warnings, fmt.Sprintf("ignoring %s in %s, it can only be set in %s", d, local, global))
+	}
+
+	return mergeConfig(config, c), warnings, nil
+}
+
+// mergeConfig overlays one config over another. Tables are merged key
+// by key so that an override only has to list what it changes.
+func mergeConfig(base, over Config) Config {
+	merged := Config{
+		Templates: mergeMaps(base.Templates, over.Templates),
+		Defaults:  mergeMaps(base.Defaults, over.Defaults),
+		Presets:   mergeMaps(base.Presets, over.Presets),
+		Repos:     mergeMaps(base.Repos, over.Repos),
+		Teams:     mergeMaps(base.Teams, over.Teams),
+		Hosts:     mergeMaps(base.Hosts, over.Hosts),
+	}
+
+	// Per command defaults are tables of their own
+	for k, v := range over.Defaults {
+		b, bok := base.Defaults[k].(map[string]any)
+		o, ook := v.(map[string]any)
+		if bok && ook {
+			merged.Defaults[k] = mergeMaps(b, o)
+		}
+	}
+
+	return merged
+}
+
+func mergeMaps[V any](base, over map[string]V) map[string]V {
+	merged := map[string]V{}
+	maps.Copy(merged, base)
+	maps.Copy(merged, over)
+	return merged
+}
+
+// configResolver fills in flags which weren't passed on the command
+// line. From highest to lowest precedence values come from:
+//
+//  1. the command line
+//  2. the preset selected with --preset
+//  3. [defaults.<command>] in the config files
+//  4. [defaults] in the config files
+//  5. the defaults built into chainlink
+//
+// with .chainlink.toml taking precedence over the global config for
+// the same setting.
+type configResolver struct {
+	config Config
+}
+
+// Validate makes sure that the config only refers to known flags so
+// that typos don't go unnoticed.
+func (r configResolver) Validate(app *kong.Application) error {
+	commands := map[string][]string{}
+	global := []string{}
+	for _, f := range app.Flags {
+		global = append(global, f.Name)
+	}
+	for _, cmd := range app.Children {
+		for _, f := range cmd.Flags {
+			commands[cmd.Name] = append(commands[cmd.Name], f.Name)
+		}
+	}
+
+	known := func(name string) bool {
+		if slices.Contains(global, name) {
+			return true
+		}
+		for _, flags := range commands {
+			if slices.Contains(flags, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for key, value := range r.config.Defaults {
+		if table, ok := value.(map[string]any); ok {
+			flags, ok := commands[key]
+			if !ok {
+				return fmt.Errorf("config: unknown command [defaults.%s]", key)
+			}
+			for name := range table {
+				if !slices.Contains(flags, name) && !slices.Contains(global, name) {
+					return fmt.Errorf("config: unknown flag %q in [defaults.%s]", name, key)
+				}
+			}
+			continue
+		}
+
+		if !known(key) {
+			return fmt.Errorf("config: unknown flag %q in [defaults]", key)
+		}
+	}
+
+	for preset, values := range r.config.Presets {
+		for name := range values {
+			if !known(name) || name == "preset" {
+				return fmt.Errorf("config: unknown flag %q in [presets.%s]", name, preset)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r configResolver) Resolve(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+	command := ""
+	if selected := ctx.Selected(); selected != nil {
+		// Positional arguments are nodes too, find their command
+		for n := selected; n != nil; n = n.Parent {
+			if n.Type == kong.CommandNode {
+				command = n.Name
+				break
+			}
+		}
+	}
+
+	for _, f := range ctx.Flags() {
+		if f.Name != "preset" {
+			continue
+		}
+
+		name, _ := ctx.FlagValue(f).(string)
+		if len(name) == 0 {
+			break
+		}
+
+		// Unknown presets are reported by checkPreset after parsing
+		if v, ok := r.config.Presets[name][flag.Name]; ok {
+			return v, nil
+		}
+	}
+
+	if table, ok := r.config.Defaults[command].(map[string]any); ok {
+		if v, ok := table[flag.Name]; ok {
+			return v, nil
+		}
+	}
+
+	if v, ok := r.config.Defaults[flag.Name]; ok {
+		if _, isTable := v.(map[string]any); !isTable {
+			return v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkPreset makes sure that the preset passed to --preset exists
+func (c Config) checkPreset(name string) error {
+	if _, ok := c.Presets[name]; len(name) == 0 || ok {
+		return nil
+	}
+
+	names := []string{}
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
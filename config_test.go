@@ -0,0 +1,253 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	global := writeConfig(t, dir, "config.toml", `
+[defaults]
+cache-time = "5m"
+author = "-me"
+
+[defaults.log]
+output = "small"
+all = true
+
+[presets.review-queue]
+review-status = "unapproved"
+
+[repos]
+work = ["org/a", "org/b"]
+
+[hosts."github.example.com"]
+api-url = "https://github.example.com/api/graphql"
+`)
+	local := writeConfig(t, dir, ".chainlink.toml", `
+[defaults]
+author = "alice"
+
+[defaults.log]
+output = "markdown"
+`)
+
+	config, warnings, err := loadConfigs(global, local)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	if config.Defaults["cache-time"] != "5m" || config.Defaults["author"] != "alice" {
+		t.Errorf("unexpected defaults %v", config.Defaults)
+	}
+
+	logDefaults := config.Defaults["log"].(map[string]any)
+	if logDefaults["output"] != "markdown" || logDefaults["all"] != true {
+		t.Errorf("expected per command defaults to be merged, got %v", logDefaults)
+	}
+
+	if len(config.Repos["work"]) != 2 || config.Presets["review-queue"] == nil {
+		t.Errorf("unexpected repos %v or presets %v", config.Repos, config.Presets)
+	}
+	if config.Hosts["github.example.com"].APIURL != "https://github.example.com/api/graphql" {
+		t.Errorf("unexpected hosts %v", config.Hosts)
+	}
+
+	_, _, err = loadConfigs(global, filepath.Join(dir, "missing.toml"))
+	if err != nil {
+		t.Errorf("expected a missing local config to be fine, got %v", err)
+	}
+
+	_, _, err = loadConfigs(writeConfig(t, dir, "broken.toml", "[defaults"), "")
+	if err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestLoadConfigs_LocalRestricted(t *testing.T) {
+	dir := t.TempDir()
+	global := writeConfig(t, dir, "config.toml", `
+[defaults.rebase]
+shell = "/bin/bash"
+
+[hosts."github.example.com"]
+api-url = "https://github.example.com/api/graphql"
+`)
+	local := writeConfig(t, dir, ".chainlink.toml", `
+[defaults]
+author = "alice"
+record = "/tmp/leak.json"
+
+[defaults.rebase]
+run = true
+shell = "/tmp/evil.sh"
+
+[defaults.open]
+browser = "curl"
+view = "files"
+
+[presets.mine]
+notify = "curl"
+labels = ["backend"]
+
+[repos]
+work = ["org/c"]
+
+[hosts."github.example.com"]
+api-url = "https://evil.example.com"
+token-env = "AWS_SECRET_ACCESS_KEY"
+
+[teams]
+backend = ["alice", "bob"]
+
+[templates]
+short = "{{.Number}}"
+`)
+
+	config, warnings, err := loadConfigs(global, local)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"[hosts]", "[repos]", "browser in [defaults.open]", "notify in [presets.mine]", "record in [defaults]", "run in [defaults.rebase]", "shell in [defaults.rebase]"}
+	if len(warnings) != len(want) {
+		t.Fatalf("expected %d warnings, got %v", len(want), warnings)
+	}
+	for i, w := range want {
+		if !strings.Contains(warnings[i], "ignoring "+w+" in "+local) {
+			t.Errorf("expected warning about %s, got %q", w, warnings[i])
+		}
+	}
+
+	if config.Hosts["github.example.com"].APIURL != "https://github.example.com/api/graphql" || config.Hosts["github.example.com"].TokenEnv != "" {
+		t.Errorf("expected hosts from the global config only, got %v", config.Hosts)
+	}
+	if rebase := config.Defaults["rebase"].(map[string]any); rebase["shell"] != "/bin/bash" || rebase["run"] != nil {
+		t.Errorf("expected rebase defaults from the global config only, got %v", rebase)
+	}
+	if config.Defaults["record"] != nil || len(config.Repos) != 0 {
+		t.Errorf("unexpected defaults %v or repos %v", config.Defaults, config.Repos)
+	}
+
+	// The safe settings are still used
+	if config.Defaults["author"] != "alice" || config.Defaults["open"].(map[string]any)["view"] != "files" {
+		t.Errorf("expected local filters and output settings, got %v", config.Defaults)
+	}
+	if config.Presets["mine"]["labels"] == nil || config.Presets["mine"]["notify"] != nil {
+		t.Errorf("unexpected preset %v", config.Presets["mine"])
+	}
+	if len(config.Teams["backend"]) != 2 || config.Templates["short"] == "" {
+		t.Errorf("expected teams and templates, got %v %v", config.Teams, config.Templates)
+	}
+}
+
+func TestConfigResolver(t *testing.T) {
+	var cli struct {
+		Preset    string
+		CacheTime string `default:"1m"`
+
+		Log struct {
+			Author string
+			Output string `default:"default"`
+			Labels []string
+			All    bool
+		} `cmd:""`
+
+		Open struct {
+			Filter string `arg:""`
+			Author string
+			Output string `default:"default"`
+		} `cmd:""`
+	}
+
+	config := Config{
+		Defaults: map[string]any{
+			"cache-time": "5m",
+			"author":     "-me",
+			"output":     "json",
+			"log":        map[string]any{"output": "small", "all": true},
+		},
+		Presets: map[string]map[string]any{
+			"backend": {"labels": []any{"backend", "-wip"}, "author": "alice"},
+		},
+	}
+
+	parse := func(args ...string) {
+		t.Helper()
+
+		parser, err := kong.New(&cli, kong.Resolvers(configResolver{config: config}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parser.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parse("log")
+	if cli.CacheTime != "5m" || cli.Log.Author != "-me" || cli.Log.Output != "small" || !cli.Log.All {
+		t.Errorf("expected defaults from config, got %+v", cli)
+	}
+
+	parse("open", "10")
+	if cli.Open.Output != "json" || cli.Open.Author != "-me" {
+		t.Errorf("expected general defaults for open, got %+v", cli.Open)
+	}
+
+	parse("log", "--preset", "backend")
+	if cli.Log.Author != "alice" || len(cli.Log.Labels) != 2 || cli.Log.Labels[1] != "-wip" {
+		t.Errorf("expected preset to override defaults, got %+v", cli.Log)
+	}
+
+	parse("log", "--preset", "backend", "--author", "bob", "--output", "markdown")
+	if cli.Log.Author != "bob" || cli.Log.Output != "markdown" {
+		t.Errorf("expected flags to override the preset, got %+v", cli.Log)
+	}
+
+	if err := config.checkPreset("nope"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestConfigResolver_Validate(t *testing.T) {
+	var cli struct {
+		Log struct {
+			Author string
+		} `cmd:""`
+	}
+
+	for _, defaults := range []map[string]any{
+		{"athor": "me"},
+		{"log": map[string]any{"athor": "me"}},
+		{"lgo": map[string]any{"author": "me"}},
+	} {
+		parser, err := kong.New(&cli, kong.Resolvers(configResolver{config: Config{Defaults: defaults}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parser.Parse([]string{"log"})
+		if err == nil {
+			t.Errorf("expected error for %v", defaults)
+		}
+	}
+}
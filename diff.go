@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// prChange is a change to a PR between two snapshots
+type prChange struct {
+	number int
+	field  string // "review", "checks", "mergeable", "base", "added", "removed"
+	from   string
+	to     string
+}
+
+func (c prChange) String() string {
+	switch c.field {
+	case "added", "removed":
+		return fmt.Sprintf("#%d %s", c.number, c.describe())
+	default:
+		return fmt.Sprintf("#%d %s: %s → %s", c.number, c.field, orNone(c.from), orNone(c.to))
+	}
+}
+
+// describe is the change without the PR number, for listing it under
+// the PR's title
+func (c prChange) describe() string {
+	switch c.field {
+	case "added":
+		return "opened"
+	case "removed":
+		return "merged or closed"
+	default:
+		return fmt.Sprintf("%s %s → %s", c.field, orNone(c.from), orNone(c.to))
+	}
+}
+
+func orNone(s string) string {
+	if len(s) == 0 {
+		return "none"
+	}
+	return s
+}
+
+// reviewState summarizes the reviews of a PR
+func reviewState(p pr) string {
+	switch {
+	case p.hasChangesRequested:
+		return "changes-requested"
+	case len(p.approvers) > 0:
+		return "approved"
+	default:
+		return "pending"
+	}
+}
+
+// diffPRs lists the changes between two snapshots, ordered by PR number
+func diffPRs(prev, cur map[int]pr) []prChange {
+	nums := []int{}
+	for n := range prev {
+		nums = append(nums, n)
+	}
+	for n := range cur {
+		if _, ok := prev[n]; !ok {
+			nums = append(nums, n)
+		}
+	}
+	slices.Sort(nums)
+
+	changes := []prChange{}
+	for _, n := range nums {
+		p, inPrev := prev[n]
+		c, inCur := cur[n]
+
+		switch {
+		case !inPrev:
+			changes = append(changes, prChange{number: n, field: "added"})
+			continue
+		case !inCur:
+			changes = append(changes, prChange{number: n, field: "removed"})
+			continue
+		}
+
+		fields := []struct {
+			name     string
+			from, to string
+		}{
+			{"review", reviewState(p), reviewState(c)},
+			{"checks", p.checksState, c.checksState},
+			{"mergeable", p.mergeable, c.mergeable},
+			{"base", p.base, c.base},
+		}
+		for _, f := range fields {
+			if f.from != f.to {
+				changes = append(changes, prChange{number: n, field: f.name, from: f.from, to: f.to})
+			}
+		}
+	}
+
+	return changes
+}
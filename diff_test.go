@@ -0,0 +1,40 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffPRs(t *testing.T) {
+	prev := map[int]pr{
+		1: {number: 1, base: "main", checksState: "pending", mergeable: "mergeable"},
+		2: {number: 2, base: "one", approvers: []string{"bob"}, mergeable: "mergeable"},
+		3: {number: 3, base: "main"},
+	}
+	cur := map[int]pr{
+		1: {number: 1, base: "main", checksState: "failure", mergeable: "mergeable"},
+		2: {number: 2, base: "main", hasChangesRequested: true, mergeable: "conflicting"},
+		4: {number: 4, base: "main"},
+	}
+
+	got := []string{}
+	for _, c := range diffPRs(prev, cur) {
+		got = append(got, c.String())
+	}
+
+	want := []string{
+		"#1 checks: pending → failure",
+		"#2 review: approved → changes-requested",
+		"#2 mergeable: mergeable → conflicting",
+		"#2 base: one → main",
+		"#3 merged or closed",
+		"#4 opened",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if len(diffPRs(prev, prev)) != 0 {
+		t.Error("expected no changes between identical snapshots")
+	}
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary stand in for the chainlink binary, so
+// end-to-end tests can run the real CLI against the fake server.
+func TestMain(m *testing.M) {
+	if os.Getenv("CHAINLINK_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+
+	// Keep the user's own config and state from leaking into tests
+	dir, err := os.MkdirTemp("", "chainlink-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func runChainlink(t *testing.T, apiURL string, args ...string) (string, string, error) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(
+		os.Environ(),
+		"CHAINLINK_TEST_MAIN=1",
+		"CHAINLINK_API_URL="+apiURL,
+		"CHAINLINK_TOKEN=test-token",
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestE2E_LogDefault(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	for _, want := range []string{
+		"Basic code for backup cleanup (alice) [cleanup]",
+		"Add mod time to models (alice) [model-mod-time]",
+		"Group CLI (carol) [group-cli]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// Standalone PRs are only shown with --all
+	if strings.Contains(out, "Fix typo in README") {
+		t.Errorf("did not expect standalone PR in output:\n%s", out)
+	}
+}
+
+func TestE2E_LogJSON(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache", "--all", "--output", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal([]byte(out), &output); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out)
+	}
+
+	if len(output.Chains) != 3 {
+		t.Fatalf("expected 3 chains, got %d", len(output.Chains))
+	}
+
+	root := output.Chains[0]
+	if root.PullRequest.Number != 10 || root.PullRequest.ApprovedBy != "bob" {
+		t.Errorf("unexpected root PR %+v", root.PullRequest)
+	}
+	if len(root.Children) != 1 || root.Children[0].PullRequest.Number != 11 {
+		t.Fatalf("expected #11 as child of #10, got %+v", root.Children)
+	}
+	if len(root.Children[0].Children) != 1 || root.Children[0].Children[0].PullRequest.Number != 12 {
+		t.Errorf("expected #12 as child of #11, got %+v", root.Children[0].Children)
+	}
+}
+
+func TestE2E_LogFiltered(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache", "--output", "small", "--checks", "fail")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	if strings.TrimSpace(out) != "#11 Add mod time to models ✗" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestE2E_OpenPrint(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "open", "--repo", "test/repo", "--no-cache", "--print", "model-mod-time")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	want := "https://github.com/test/repo/pull/10\nhttps://github.com/test/repo/pull/11\nhttps://github.com/test/repo/pull/12\n"
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestE2E_Rebase(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "rebase", "--repo", "test/repo", "--no-cache", "--push", "20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	want := strings.Join([]string{
+		"#!/bin/sh",
+		"",
+		"set -e",
+		"",
+		"git checkout group-cli",
+		"git rebase --update-refs main",
+		"git push --force-with-lease group-files",
+		"git push --force-with-lease group-cli",
+	}, "\n") + "\n"
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestE2E_MissingRepo(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	_, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/missing", "--no-cache")
+	if err == nil {
+		t.Fatal("expected command to fail")
+	}
+	if !strings.Contains(stderr, "Could not resolve to a Repository") {
+		t.Errorf("expected GraphQL error in stderr, got:\n%s", stderr)
+	}
+}
+
+func TestE2E_InvalidFilters(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	tests := [][]string{
+		{"log", "--where", "author =="},
+		{"log", "--chain-any", "bogus=1"},
+		{"log", "--created-since", "yesterday"},
+		{"log", "--watch", "soon"},
+		{"open", "--where", "draft =="},
+		{"tui", "--filter", "bogus=1"},
+	}
+
+	for _, args := range tests {
+		args = append(args, "--repo", "test/repo", "--no-cache")
+		_, _, err := runChainlink(t, f.URL, args...)
+		if err == nil {
+			t.Errorf("%v: expected command to fail", args)
+		}
+	}
+
+	// Nothing should be fetched only to fail on a typo
+	if f.requestCount() != 0 {
+		t.Errorf("expected no requests, got %d", f.requestCount())
+	}
+}
+
+func TestE2E_RecordReplay(t *testing.T) {
+	f := newFakeGitHub(t)
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recorded, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--all", "--output", "json", "--record", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	// The replay must not need the server at all
+	replayed, stderr, err := runChainlink(t, "http://127.0.0.1:0", "log", "--all", "--output", "json", "--replay", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	if recorded != replayed {
+		t.Errorf("replayed output differs\nrecorded:\n%s\nreplayed:\n%s", recorded, replayed)
+	}
+}
+
+func TestE2E_LogProblems(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/broken", "--no-cache", "--output", "small")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	if !strings.Contains(stderr, "warning: PRs #1, #2 form a cycle") {
+		t.Errorf("expected cycle warning, got:\n%s", stderr)
+	}
+	if !strings.Contains(out, "#1 Cycle one\n  #2 Cycle two\n") {
+		t.Errorf("expected cycle to be shown as a chain, got:\n%s", out)
+	}
+
+	out, stderr, err = runChainlink(t, f.URL, "log", "--repo", "test/broken", "--no-cache", "--output", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal([]byte(out), &output); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out)
+	}
+	if len(output.Problems) != 4 {
+		t.Errorf("expected 4 problems, got %+v", output.Problems)
+	}
+}
+
+func TestE2E_LogFormat(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	config := t.TempDir()
+	err := os.MkdirAll(filepath.Join(config, "chainlink"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(config, "chainlink", "config.toml"),
+		[]byte("[templates]\nposition = \"{{.Number}} {{.Position}}/{{.ChainLength}}\"\n"),
+		0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", config)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache", "--output", "small", "--format", "position")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	want := "10 1/3\n  11 2/3\n    12 3/3\n20 1/2\n  21 2/2\n"
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestE2E_ConfigPresets(t *testing.T) {
+	f := newFakeGitHub(t)
+
+	config := t.TempDir()
+	err := os.MkdirAll(filepath.Join(config, "chainlink"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(config, "chainlink", "config.toml"),
+		[]byte(`
+[defaults.log]
+output = "small"
+
+[presets.failing]
+checks = "fail"
+
+[repos]
+both = ["test/repo", "test/broken"]
+`),
+		0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", config)
+
+	out, stderr, err := runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache", "--preset", "failing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if strings.TrimSpace(out) != "#11 Add mod time to models ✗" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	out, stderr, err = runChainlink(t, f.URL, "log", "--repo", "both", "--no-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(out, "test/repo\n#10 ") || !strings.Contains(out, "test/broken\n") {
+		t.Errorf("expected a section per repo, got:\n%s", out)
+	}
+
+	_, stderr, err = runChainlink(t, f.URL, "log", "--repo", "test/repo", "--no-cache", "--preset", "missing")
+	if err == nil || !strings.Contains(stderr, `unknown preset "missing"`) {
+		t.Errorf("expected unknown preset error, got %v:\n%s", err, stderr)
+	}
+}
+
+func TestE2E_Diff(t *testing.T) {
+	f := newFakeGitHub(t)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	out, stderr, err := runChainlink(t, f.URL, "diff", "--repo", "test/repo", "--no-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(out, "No previous snapshot") {
+		t.Errorf("unexpected output for first run:\n%s", out)
+	}
+
+	// Build a later state of the repo: #11 went green, #21 was
+	// merged and #13 was added on top of #12.
+	bts, err := os.ReadFile("testdata/github/test/repo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := Recording{Org: "test", Repo: "repo"}
+	if err := json.Unmarshal(bts, &rec.Response); err != nil {
+		t.Fatal(err)
+	}
+	edges := rec.Response.Data.Repository.PullRequests.Edges
+	later := edges[:0:0]
+	for _, e := range edges {
+		switch e.Node.Number {
+		case 11:
+			e.Node.Commits.Nodes[0].Commit.StatusCheckRollup.State = "SUCCESS"
+		case 21:
+			continue
+		case 12:
+			added := e
+			added.Node.Number = 13
+			added.Node.Title = "Clean up old models"
+			added.Node.HeadRefName = "cleanup-old-models"
+			added.Node.BaseRefName = "delay-model-gc"
+			later = append(later, added)
+		}
+		later = append(later, e)
+	}
+	rec.Response.Data.Repository.PullRequests.Edges = later
+
+	path := filepath.Join(t.TempDir(), "later.json")
+	bts, _ = json.Marshal(rec)
+	if err := os.WriteFile(path, bts, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, stderr, err = runChainlink(t, f.URL, "diff", "--replay", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	want := strings.Join([]string{
+		"#10 Basic code for backup cleanup",
+		"  #11 Add mod time to models: checks failure → success",
+		"  #13 Clean up old models: opened",
+		"#20 Group files",
+		"  #21 Group CLI: merged or closed",
+	}, "\n") + "\n"
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+
+	// The snapshot was updated, so nothing changed since
+	out, stderr, err = runChainlink(t, f.URL, "diff", "--replay", path, "--output", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var output JSONDiffOutput
+	if err := json.Unmarshal([]byte(out), &output); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out)
+	}
+	if output.Since == nil || len(output.Chains) != 0 {
+		t.Errorf("expected no changes, got %s", out)
+	}
+}
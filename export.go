@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// flatPR is a PR along with where it sits in its chain, used for the
+// csv, tsv and ndjson outputs which have one row per PR.
+type flatPR struct {
+	Group    string `json:"group"`    // only with --group-by
+	Chain    int    `json:"chain"`    // 1-based index of the chain
+	Root     int    `json:"root"`     // number of the first PR in the chain
+	Depth    int    `json:"depth"`    // nesting level, 0 for the root
+	Parent   int    `json:"parent"`   // closest displayed ancestor, 0 for the root
+	Position int    `json:"position"` // 1-based position within the chain
+	JSONPullRequest
+}
+
+// flatColumns lists the available columns in their default order
+func flatColumns() []string {
+	columns := []string{}
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous {
+				collect(f.Type)
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			columns = append(columns, name)
+		}
+	}
+	collect(reflect.TypeOf(flatPR{}))
+
+	return columns
+}
+
+func flattenChains(d data, nodes []treeNode) []flatPR {
+	rows := []flatPR{}
+
+	var walk func(nodes []treeNode, chain, root, depth, parent int, position *int)
+	walk = func(nodes []treeNode, chain, root, depth, parent int, position *int) {
+		for _, n := range nodes {
+			*position++
+			rows = append(rows, flatPR{
+				Chain:           chain,
+				Root:            root,
+				Depth:           depth,
+				Parent:          parent,
+				Position:        *position,
+				JSONPullRequest: toJSONPullRequest(d.prs[n.number], d.url),
+			})
+			walk(n.children, chain, root, depth+1, n.number, position)
+		}
+	}
+
+	for i, n := range nodes {
+		position := 0
+		walk([]treeNode{n}, i+1, n.number, 0, 0, &position)
+	}
+
+	return rows
+}
+
+// selectColumns validates the requested columns, defaulting to all.
+// The group column is only available when grouping.
+func selectColumns(requested []string, grouped bool) ([]string, error) {
+	available := flatColumns()
+	if !grouped {
+		available = slices.DeleteFunc(available, func(c string) bool { return c == "group" })
+	}
+	if len(requested) == 0 {
+		return available, nil
+	}
+
+	for _, c := range requested {
+		if !slices.Contains(available, c) {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", c, strings.Join(available, ","))
+		}
+	}
+
+	return requested, nil
+}
+
+// rowFields returns the JSON encoded value of every column of a row.
+// Fields left out of the JSON, like the blockedBy of a ready PR, are
+// null.
+func rowFields(row flatPR) (map[string]json.RawMessage, error) {
+	bts, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(bts, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range flatColumns() {
+		if _, ok := fields[c]; !ok {
+			fields[c] = json.RawMessage("null")
+		}
+	}
+	return fields, nil
+}
+
+// cellValue converts a JSON value into plain text for csv/tsv. Lists
+// are joined with commas.
+func cellValue(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return strings.Join(list, ",")
+	}
+
+	if string(raw) == "null" {
+		return ""
+	}
+
+	return string(raw)
+}
+
+func formatDelimited(rows []flatPR, columns []string, comma rune) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+
+	err := w.Write(columns)
+	if err != nil {
+		return "", err
+	}
+
+	for _, row := range rows {
+		fields, err := rowFields(row)
+		if err != nil {
+			return "", err
+		}
+
+		record := make([]string, 0, len(columns))
+		for _, c := range columns {
+			record = append(record, cellValue(fields[c]))
+		}
+
+		err = w.Write(record)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func formatNDJSON(rows []flatPR, columns []string) (string, error) {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		fields, err := rowFields(row)
+		if err != nil {
+			return "", err
+		}
+
+		// Written by hand to keep the keys in column order
+		buf.WriteByte('{')
+		for i, c := range columns {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(c)
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(fields[c])
+		}
+		buf.WriteString("}\n")
+	}
+
+	return buf.String(), nil
+}
+
+// formatFlat renders the chains as one row per PR in the csv, tsv or
+// ndjson format.
+func formatFlat(d data, mappings map[int]mapping, output string, columns []string, opts FilterOptions) (string, error) {
+	return formatFlatGroups(d, mappings, output, columns, []chainGroup{{opts: opts}})
+}
+
+// formatFlatGroups is formatFlat with a group column naming the
+// --group-by section of each row.
+func formatFlatGroups(d data, mappings map[int]mapping, output string, columns []string, groups []chainGroup) (string, error) {
+	columns, err := selectColumns(columns, isGrouped(groups))
+	if err != nil {
+		return "", err
+	}
+
+	rows := []flatPR{}
+	chains := 0
+	for _, g := range groups {
+		groupRows := flattenChains(d, visibleTree(d, mappings, 0, g.opts))
+		for _, row := range groupRows {
+			row.Group = g.name
+			row.Chain += chains
+			rows = append(rows, row)
+		}
+		if len(groupRows) > 0 {
+			chains += groupRows[len(groupRows)-1].Chain
+		}
+	}
+
+	switch output {
+	case "csv":
+		return formatDelimited(rows, columns, ',')
+	case "tsv":
+		return formatDelimited(rows, columns, '\t')
+	default:
+		return formatNDJSON(rows, columns)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func flatTestData() data {
+	// 0 -> 1 -> 2 -> 3
+	//        -> 4
+	// 0 -> 5
+	return makeTestData(
+		map[int]pr{
+			1: {number: 1, title: "One", author: "alice", labels: []string{"a", "b"}},
+			2: {number: 2, title: "Two, with comma", author: "bob"},
+			3: {number: 3, title: "Three", author: "alice"},
+			4: {number: 4, title: "Four", author: "alice"},
+			5: {number: 5, title: "Five", author: "carol"},
+		},
+		map[int]mapping{
+			0: {following: []int{1, 5}},
+			1: {base: 0, following: []int{2, 4}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+			4: {base: 1, following: []int{}},
+			5: {base: 0, following: []int{}},
+		},
+	)
+}
+
+func TestFlattenChains(t *testing.T) {
+	d := flatTestData()
+
+	rows := flattenChains(d, visibleTree(d, d.mappings, 0, FilterOptions{Author: "-bob"}))
+
+	type pos struct{ number, chain, root, depth, parent, position int }
+	got := []pos{}
+	for _, r := range rows {
+		got = append(got, pos{r.Number, r.Chain, r.Root, r.Depth, r.Parent, r.Position})
+	}
+
+	want := []pos{
+		{1, 1, 1, 0, 0, 1},
+		{3, 1, 1, 1, 1, 2}, // promoted in place of #2
+		{4, 1, 1, 1, 1, 3},
+		{5, 2, 5, 0, 0, 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	all, err := selectColumns(nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(all[:6], []string{"chain", "root", "depth", "parent", "position", "number"}) {
+		t.Errorf("unexpected default columns %v", all)
+	}
+	if !slices.Contains(all, "checksState") {
+		t.Errorf("expected every JSON field to be a column, got %v", all)
+	}
+
+	_, err = selectColumns([]string{"number", "nope"}, false)
+	if err == nil {
+		t.Error("expected error for unknown column")
+	}
+
+	_, err = selectColumns([]string{"group"}, false)
+	if err == nil {
+		t.Error("expected group column to need --group-by")
+	}
+
+	grouped, err := selectColumns(nil, true)
+	if err != nil || grouped[0] != "group" {
+		t.Errorf("expected group as the first column when grouping, got %v (%v)", grouped, err)
+	}
+}
+
+func TestFormatFlat(t *testing.T) {
+	d := flatTestData()
+	columns := []string{"chain", "number", "parent", "title", "labels"}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			"csv",
+			strings.Join([]string{
+				"chain,number,parent,title,labels",
+				`1,1,0,One,"a,b"`,
+				`1,2,1,"Two, with comma",`,
+				"1,3,2,Three,",
+				"1,4,1,Four,",
+				"2,5,0,Five,",
+			}, "\n") + "\n",
+		},
+		{
+			"tsv",
+			strings.Join([]string{
+				"chain\tnumber\tparent\ttitle\tlabels",
+				"1\t1\t0\tOne\ta,b",
+				"1\t2\t1\tTwo, with comma\t",
+				"1\t3\t2\tThree\t",
+				"1\t4\t1\tFour\t",
+				"2\t5\t0\tFive\t",
+			}, "\n") + "\n",
+		},
+		{
+			"ndjson",
+			strings.Join([]string{
+				`{"chain":1,"number":1,"parent":0,"title":"One","labels":["a","b"]}`,
+				`{"chain":1,"number":2,"parent":1,"title":"Two, with comma","labels":null}`,
+				`{"chain":1,"number":3,"parent":2,"title":"Three","labels":null}`,
+				`{"chain":1,"number":4,"parent":1,"title":"Four","labels":null}`,
+				`{"chain":2,"number":5,"parent":0,"title":"Five","labels":null}`,
+			}, "\n") + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := formatFlat(d, d.mappings, tt.output, columns, FilterOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFlat_ReadyPR(t *testing.T) {
+	d := flatTestData()
+
+	p := d.prs[5]
+	p.reviewDecision = "approved"
+	d.prs[5] = p
+
+	out, err := formatFlat(d, d.mappings, "ndjson", nil, FilterOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d:\n%s", len(lines), out)
+	}
+
+	var ready map[string]any
+	for _, line := range lines {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, line)
+		}
+		if row["number"] == float64(5) {
+			ready = row
+		}
+	}
+
+	if ready["status"] != "ready" {
+		t.Fatalf("expected #5 to be ready, got %v", ready["status"])
+	}
+	if blockedBy, ok := ready["blockedBy"]; !ok || blockedBy != nil {
+		t.Errorf("expected blockedBy to be null, got %v", blockedBy)
+	}
+
+	out, err = formatFlat(d, d.mappings, "csv", []string{"number", "blockedBy", "title"}, FilterOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "\n5,,Five\n") {
+		t.Errorf("expected an empty blockedBy cell for #5, got:\n%s", out)
+	}
+}
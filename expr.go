@@ -0,0 +1,730 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterExpr is a node of a parsed --where expression. The filter
+// flags are compiled into the same nodes by compileFilters.
+type filterExpr interface {
+	match(p pr) bool
+	String() string
+}
+
+// andExpr matches if all of its operands do, an empty one always matches
+type andExpr []filterExpr
+
+func (e andExpr) match(p pr) bool {
+	for _, x := range e {
+		if !x.match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e andExpr) String() string {
+	if len(e) == 0 {
+		return "true"
+	}
+	return joinExprs(e, " and ")
+}
+
+// orExpr matches if any of its operands do
+type orExpr []filterExpr
+
+func (e orExpr) match(p pr) bool {
+	return slices.ContainsFunc(e, func(x filterExpr) bool { return x.match(p) })
+}
+
+func (e orExpr) String() string {
+	return joinExprs(e, " or ")
+}
+
+func joinExprs(exprs []filterExpr, sep string) string {
+	parts := []string{}
+	for _, x := range exprs {
+		s := x.String()
+		switch x.(type) {
+		case andExpr, orExpr:
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, sep)
+}
+
+type notExpr struct {
+	x filterExpr
+}
+
+func (e notExpr) match(p pr) bool {
+	return !e.x.match(p)
+}
+
+func (e notExpr) String() string {
+	switch e.x.(type) {
+	case andExpr, orExpr:
+		return "not (" + e.x.String() + ")"
+	}
+	return "not " + e.x.String()
+}
+
+// groupExpr selects the PRs of a --group-by section
+type groupExpr struct {
+	by   string
+	name string
+}
+
+func (e groupExpr) match(p pr) bool {
+	return slices.Contains(prGroups(p, e.by), e.name)
+}
+
+func (e groupExpr) String() string {
+	return fmt.Sprintf("group(%s) == %s", e.by, quoteValue(e.name))
+}
+
+// filterField is a PR attribute usable in expressions. Fields either
+// match against values or compare as numbers.
+type filterField struct {
+	values  []string                  // allowed values, anything if empty
+	is      func(p pr, v string) bool // for ==, != and in
+	number  func(p pr) float64        // for <, <=, > and >=
+	at      func(p pr) time.Time      // for times, values are durations ago like 3d or dates
+	boolean bool                      // can be used on its own, as in "not draft"
+	text    func(p pr) string         // matched against values which are globs or re: regexes
+}
+
+var filterFields = map[string]filterField{
+	"number": {number: func(p pr) float64 { return float64(p.number) }},
+	"author": {is: func(p pr, v string) bool { return p.author == v }},
+	"reviewer": {is: func(p pr, v string) bool {
+		return slices.Contains(p.reviewers, v)
+	}},
+	"label": {is: func(p pr, v string) bool {
+		return slices.Contains(p.labels, v)
+	}},
+	"title": {text: func(p pr) string { return p.title }},
+	"head":  {text: func(p pr) string { return p.head }},
+	"base":  {text: func(p pr) string { return p.base }},
+	"review": {
+		values: []string{"approved", "fully-approved", "pending", "unapproved", "changes-requested"},
+		is: func(p pr, v string) bool {
+			switch v {
+			case "approved":
+				return len(p.approvers) > 0
+			case "fully-approved":
+				return fullyApproved(p)
+			case "changes-requested":
+				return p.hasChangesRequested
+			default: // pending, unapproved
+				return len(p.approvers) == 0
+			}
+		},
+	},
+	"approvals": {number: func(p pr) float64 { return float64(len(p.approvers)) }},
+	"checks": {
+		values: []string{"pass", "fail", "pending"},
+		is: func(p pr, v string) bool {
+			switch v {
+			case "pass":
+				return p.checksState == "success"
+			case "fail":
+				return p.checksState == "failure" || p.checksState == "error"
+			default: // pending
+				return p.checksState == "pending" || p.checksState == "expected"
+			}
+		},
+	},
+	"mergeable": {
+		values: []string{"mergeable", "conflicting"},
+		is:     func(p pr, v string) bool { return p.mergeable == v },
+	},
+	"merge-state": {
+		values: []string{"clean", "blocked", "behind", "unstable", "dirty", "draft", "has_hooks", "unknown"},
+		is:     func(p pr, v string) bool { return p.mergeState == v },
+	},
+	"status": {
+		values: []string{"ready", "blocked"},
+		is:     func(p pr, v string) bool { return prStatus(p) == v },
+	},
+	"blocked-by": {
+		values: blockReasonNames(),
+		is:     func(p pr, v string) bool { return blockedBy(p) == v },
+	},
+	"size": {
+		values: []string{"small", "medium", "large"},
+		is: func(p pr, v string) bool {
+			lines := p.additions + p.deletions
+			switch v {
+			case "small":
+				return lines <= 100
+			case "medium":
+				return lines > 100 && lines <= 500
+			default: // large
+				return lines > 500
+			}
+		},
+	},
+	"lines": {number: func(p pr) float64 { return float64(p.additions + p.deletions) }},
+	"draft": {
+		values:  []string{"true", "false"},
+		is:      func(p pr, v string) bool { return p.isDraft == (v == "true") },
+		boolean: true,
+	},
+	"age":     timeField(func(p pr) time.Time { return p.createdAt }),
+	"created": timeField(func(p pr) time.Time { return p.createdAt }),
+	"updated": timeField(func(p pr) time.Time { return p.updatedAt }),
+}
+
+// timeField is a field comparing how long ago something happened.
+// Dates compare the same way, so "> 2026-10-01" means before that date
+// just like "> 3d" means more than three days ago.
+func timeField(at func(p pr) time.Time) filterField {
+	return filterField{
+		number: func(p pr) float64 { return float64(time.Since(at(p))) },
+		at:     at,
+	}
+}
+
+func filterFieldNames() []string {
+	names := []string{}
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cmpExpr compares a field against one or more values
+type cmpExpr struct {
+	field  string
+	op     string // ==, !=, <, <=, >, >= or in
+	values []string
+	number float64 // parsed value for numeric fields
+	date   bool    // number is a date in unix nanoseconds instead of a duration, see timeField
+
+	// patterns are the compiled values of text fields, see withPatterns
+	patterns []*regexp.Regexp
+}
+
+// withPatterns compiles the values of text fields once so that they
+// aren't compiled again for every PR. Invalid patterns never match,
+// they are rejected when parsing flags and expressions.
+func (e cmpExpr) withPatterns() cmpExpr {
+	if filterFields[e.field].text == nil {
+		return e
+	}
+
+	e.patterns = []*regexp.Regexp{}
+	for _, v := range e.values {
+		re, _ := compilePattern(v)
+		e.patterns = append(e.patterns, re)
+	}
+	return e
+}
+
+// checkOp makes sure the operator can be used on the field
+func (f filterField) checkOp(name, op string) error {
+	switch {
+	case f.number != nil && op == "in":
+		return fmt.Errorf("%s can't be used with in", name)
+	case f.at != nil && (op == "==" || op == "!="):
+		// Times are hardly ever exactly equal to a duration or date
+		return fmt.Errorf("%s can only be compared with <, <=, > or >=", name)
+	case f.number == nil && op != "==" && op != "!=" && op != "in":
+		return fmt.Errorf("%s can only be compared with ==, != or in", name)
+	}
+	return nil
+}
+
+// parseValue validates a value for the field, returning its numeric
+// value for fields which compare as numbers
+func (f filterField) parseValue(name, v string) (float64, error) {
+	switch {
+	case f.at != nil:
+		n, _, err := parseTimeValue(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q, expected a duration like 3d or a date like 2026-10-01", name, v)
+		}
+		return n, nil
+	case f.number != nil:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q, expected a number", name, v)
+		}
+		return n, nil
+	case len(f.values) > 0 && !slices.Contains(f.values, v):
+		return 0, fmt.Errorf("invalid %s %q (%s)", name, v, strings.Join(f.values, ", "))
+	case f.text != nil:
+		if _, err := compilePattern(v); err != nil {
+			return 0, fmt.Errorf("invalid %s pattern %q: %v", name, v, err)
+		}
+	}
+	return 0, nil
+}
+
+func (e cmpExpr) match(p pr) bool {
+	f := filterFields[e.field]
+
+	if f.number != nil {
+		n, want := f.number(p), e.number
+		if e.date {
+			// Later dates are less time ago, so flip both to order
+			// them like durations
+			n, want = -float64(f.at(p).UnixNano()), -e.number
+		}
+
+		switch e.op {
+		case "==":
+			return n == want
+		case "!=":
+			return n != want
+		case "<":
+			return n < want
+		case "<=":
+			return n <= want
+		case ">":
+			return n > want
+		default: // >=
+			return n >= want
+		}
+	}
+
+	var is bool
+	if f.text != nil {
+		if len(e.patterns) != len(e.values) {
+			e = e.withPatterns()
+		}
+		s := f.text(p)
+		is = slices.ContainsFunc(e.patterns, func(re *regexp.Regexp) bool { return re != nil && re.MatchString(s) })
+	} else {
+		is = slices.ContainsFunc(e.values, func(v string) bool { return f.is(p, v) })
+	}
+	if e.op == "!=" {
+		return !is
+	}
+	return is
+}
+
+func (e cmpExpr) String() string {
+	values := []string{}
+	for _, v := range e.values {
+		values = append(values, quoteValue(v))
+	}
+
+	if e.op == "in" {
+		return fmt.Sprintf("%s in (%s)", e.field, strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", e.field, e.op, values[0])
+}
+
+// quoteValue quotes values which wouldn't be read back as a single word
+func quoteValue(v string) string {
+	if len(v) == 0 || strings.ContainsAny(v, " \t\"(),:=!<>") || isKeyword(v) {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
+
+// compileFilters turns the filter flags into an expression, ANDed with
+// the --where expression if there is one.
+func compileFilters(opts FilterOptions) filterExpr {
+	exprs := andExpr{}
+	is := func(field string, values ...string) {
+		exprs = append(exprs, cmpExpr{field: field, op: "==", values: values})
+	}
+
+	// Excluded values must all be missing, and at least one (or with
+	// all set, every one) of the included ones has to be there
+	list := func(field string, values []string, all bool) {
+		include := []string{}
+		for _, v := range values {
+			if strings.HasPrefix(v, "-") {
+				exprs = append(exprs, cmpExpr{field: field, op: "!=", values: []string{v[1:]}})
+			} else if len(v) > 0 {
+				include = append(include, v)
+			}
+		}
+		if len(include) == 1 || all {
+			for _, v := range include {
+				is(field, v)
+			}
+		} else if len(include) > 1 {
+			exprs = append(exprs, cmpExpr{field: field, op: "in", values: include})
+		}
+	}
+
+	list("author", splitList(opts.Author), false)
+
+	if len(opts.ReviewStatus) > 0 && opts.ReviewStatus != "all" {
+		is("review", opts.ReviewStatus)
+	}
+
+	if opts.MinApprovals > 0 {
+		n := strconv.Itoa(opts.MinApprovals)
+		exprs = append(exprs, cmpExpr{field: "approvals", op: ">=", values: []string{n}, number: float64(opts.MinApprovals)})
+	}
+
+	list("label", opts.Labels, opts.LabelsMode == "all")
+	list("reviewer", splitList(opts.Reviewer), false)
+
+	switch opts.DraftStatus {
+	case "draft":
+		is("draft", "true")
+	case "ready":
+		is("draft", "false")
+	}
+
+	// Durations and dates both compare how long ago PRs were created
+	// or updated. Invalid values are rejected by checkTimeFilters.
+	for _, f := range []struct{ field, value string }{
+		{"created", opts.CreatedSince},
+		{"updated", opts.UpdatedSince},
+		{"created-before", opts.CreatedBefore},
+		{"updated-before", opts.UpdatedBefore},
+	} {
+		if len(f.value) == 0 {
+			continue
+		}
+		n, date, err := parseTimeValue(f.value)
+		if err != nil {
+			continue
+		}
+
+		field, before := strings.CutSuffix(f.field, "-before")
+		c := cmpExpr{field: field, values: []string{f.value}, number: n, date: date}
+		c.op = "<="
+		if before {
+			c.op = ">"
+		}
+		if !date && field == "created" {
+			c.field = "age"
+		}
+		exprs = append(exprs, c)
+	}
+
+	for _, f := range [][2]string{{"title", opts.Title}, {"head", opts.Head}, {"base", opts.Base}} {
+		if strings.HasPrefix(f[1], "-") {
+			exprs = append(exprs, cmpExpr{field: f[0], op: "!=", values: []string{f[1][1:]}})
+		} else if len(f[1]) > 0 {
+			is(f[0], f[1])
+		}
+	}
+
+	for _, f := range [][2]string{{"size", opts.Size}, {"mergeable", opts.Mergeable}, {"merge-state", opts.MergeState}, {"checks", opts.Checks}} {
+		if len(f[1]) > 0 && f[1] != "all" {
+			is(f[0], f[1])
+		}
+	}
+
+	for i, x := range exprs {
+		if c, ok := x.(cmpExpr); ok {
+			exprs[i] = c.withPatterns()
+		}
+	}
+
+	if opts.Where != nil {
+		exprs = append(exprs, opts.Where)
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return exprs
+}
+
+// whereError is a parse error pointing at where in the expression it
+// happened
+type whereError struct {
+	input string
+	pos   int
+	msg   string
+}
+
+func (e whereError) Error() string {
+	return fmt.Sprintf("%s at column %d\n  %s\n  %s^", e.msg, e.pos+1, e.input, strings.Repeat(" ", e.pos))
+}
+
+type whereToken struct {
+	kind string // "word", "string", "op", "(", ")", ",", "eof"
+	text string
+	pos  int
+}
+
+func tokenizeWhere(input string) ([]whereToken, error) {
+	tokens := []whereToken{}
+
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, whereToken{kind: string(c), text: string(c), pos: i})
+			i++
+		case c == ':':
+			tokens = append(tokens, whereToken{kind: "op", text: ":", pos: i})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(input) && input[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, whereError{input, i, `expected "!="`}
+			}
+			tokens = append(tokens, whereToken{kind: "op", text: op, pos: i})
+			i += len(op)
+		case c == '"':
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, whereError{input, i, "unterminated string"}
+			}
+			s, err := strconv.Unquote(input[i : end+1])
+			if err != nil {
+				return nil, whereError{input, i, "invalid string"}
+			}
+			tokens = append(tokens, whereToken{kind: "string", text: s, pos: i})
+			i = end + 1
+		default:
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\n(),:=!<>\"", rune(input[i])) {
+				i++
+			}
+			tokens = append(tokens, whereToken{kind: "word", text: input[start:i], pos: start})
+		}
+	}
+
+	return append(tokens, whereToken{kind: "eof", pos: len(input)}), nil
+}
+
+// whereParser is a recursive descent parser for:
+//
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | comparison
+//	comparison = field [ op value | "in" "(" value { "," value } ")" ]
+type whereParser struct {
+	input  string
+	tokens []whereToken
+	pos    int
+}
+
+// parseWhere parses a --where expression, returning nil for an empty one
+func parseWhere(input string) (filterExpr, error) {
+	if len(strings.TrimSpace(input)) == 0 {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeWhere(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &whereParser{input: input, tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != "eof" {
+		return nil, p.errorf(t, "unexpected %s", describeToken(t))
+	}
+
+	return e, nil
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *whereParser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == "word" && t.text == word
+}
+
+func (p *whereParser) errorf(t whereToken, format string, args ...any) error {
+	return whereError{input: p.input, pos: t.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+func describeToken(t whereToken) string {
+	switch t.kind {
+	case "eof":
+		return "end of expression"
+	case "string":
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func (p *whereParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := orExpr{left}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *whereParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := andExpr{left}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *whereParser) parseUnary() (filterExpr, error) {
+	if p.isKeyword("not") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	}
+
+	if p.peek().kind == "(" {
+		open := p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != ")" {
+			return nil, p.errorf(t, "expected \")\" to close the \"(\" at column %d, got %s", open.pos+1, describeToken(t))
+		}
+		return x, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (filterExpr, error) {
+	field := p.next()
+	if field.kind != "word" || isKeyword(field.text) {
+		return nil, p.errorf(field, "expected a field, got %s", describeToken(field))
+	}
+
+	f, ok := filterFields[field.text]
+	if !ok {
+		return nil, p.errorf(field, "unknown field %q (available: %s)", field.text, strings.Join(filterFieldNames(), ", "))
+	}
+
+	op := p.peek()
+	values := []whereToken{}
+	switch {
+	case op.kind == "op":
+		p.next()
+		value := p.next()
+		if value.kind != "word" && value.kind != "string" {
+			return nil, p.errorf(value, "expected a value after %q, got %s", op.text, describeToken(value))
+		}
+		values = append(values, value)
+	case op.kind == "word" && op.text == "in":
+		p.next()
+		if t := p.next(); t.kind != "(" {
+			return nil, p.errorf(t, "expected \"(\" after in, got %s", describeToken(t))
+		}
+		for {
+			value := p.next()
+			if value.kind != "word" && value.kind != "string" {
+				return nil, p.errorf(value, "expected a value, got %s", describeToken(value))
+			}
+			values = append(values, value)
+
+			t := p.next()
+			if t.kind == ")" {
+				break
+			}
+			if t.kind != "," {
+				return nil, p.errorf(t, "expected \",\" or \")\", got %s", describeToken(t))
+			}
+		}
+	case f.boolean:
+		// A bare boolean field, as in "not draft"
+		return cmpExpr{field: field.text, op: "==", values: []string{"true"}}, nil
+	default:
+		return nil, p.errorf(op, "expected an operator after %q, got %s", field.text, describeToken(op))
+	}
+
+	c := cmpExpr{field: field.text, op: op.text}
+	if c.op == ":" || c.op == "=" {
+		c.op = "=="
+	}
+
+	if err := f.checkOp(field.text, c.op); err != nil {
+		return nil, p.errorf(op, "%v", err)
+	}
+
+	for _, v := range values {
+		n, err := f.parseValue(field.text, v.text)
+		if err != nil {
+			return nil, p.errorf(v, "%v", err)
+		}
+		c.values = append(c.values, v.text)
+		c.number = n
+		if f.at != nil {
+			_, c.date, _ = parseTimeValue(v.text)
+		}
+	}
+
+	return c.withPatterns(), nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"author == alice", "author == alice"},
+		{"author = alice", "author == alice"},
+		{"label:wip", "label == wip"},
+		{"not label:wip", "not label == wip"},
+		{"draft", "draft == true"},
+		{"not draft and checks != fail", "not draft == true and checks != fail"},
+		{"author in (alice, bob)", "author in (alice, bob)"},
+		{`title == "fix: typo"`, `title == "fix: typo"`},
+		{
+			"author in (alice,bob) and (checks == fail or mergeable == conflicting) and age > 3d and not label:wip",
+			"author in (alice, bob) and (checks == fail or mergeable == conflicting) and age > 3d and not label == wip",
+		},
+		{"not (review == approved or draft)", "not (review == approved or draft == true)"},
+		{"checks == pass or checks == pending and lines < 100", "checks == pass or (checks == pending and lines < 100)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			e, err := parseWhere(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.String() != tt.want {
+				t.Errorf("got %q, want %q", e.String(), tt.want)
+			}
+
+			// The printed form parses back to the same expression
+			again, err := parseWhere(e.String())
+			if err != nil || again.String() != e.String() {
+				t.Errorf("expected %q to round trip, got %v (%v)", e.String(), again, err)
+			}
+		})
+	}
+
+	e, err := parseWhere("  ")
+	if e != nil || err != nil {
+		t.Errorf("expected nothing for an empty expression, got %v (%v)", e, err)
+	}
+}
+
+func TestParseWhere_Errors(t *testing.T) {
+	tests := []struct {
+		input  string
+		msg    string
+		column int
+	}{
+		{"colour == red", `unknown field "colour"`, 1},
+		{"checks == green", `invalid checks "green"`, 11},
+		{"author > bob", "author can only be compared with ==, != or in", 8},
+		{"age in (3d)", "age can't be used with in", 5},
+		{"created == 2026-10-01", "created can only be compared with <, <=, > or >=", 9},
+		{"updated != 3d", "updated can only be compared with <, <=, > or >=", 9},
+		{"age > soon", `invalid age "soon"`, 7},
+		{"author in (alice,bob", `expected "," or ")", got end of expression`, 21},
+		{"(author == alice", `expected ")" to close the "(" at column 1`, 17},
+		{"author ==", `expected a value after "=="`, 10},
+		{"author alice", `expected an operator after "author", got "alice"`, 8},
+		{"author == alice bob", `unexpected "bob"`, 17},
+		{"and author == alice", "expected a field", 1},
+		{`title == "oops`, "unterminated string", 10},
+		{"author ! alice", `expected "!="`, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseWhere(tt.input)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			werr, ok := err.(whereError)
+			if !ok {
+				t.Fatalf("expected a whereError, got %T", err)
+			}
+			if !strings.Contains(werr.msg, tt.msg) {
+				t.Errorf("expected message to contain %q, got %q", tt.msg, werr.msg)
+			}
+			if werr.pos+1 != tt.column {
+				t.Errorf("expected error at column %d, got %d", tt.column, werr.pos+1)
+			}
+		})
+	}
+}
+
+func TestWhereMatch(t *testing.T) {
+	p := pr{
+		number:      7,
+		title:       "Add stats",
+		author:      "alice",
+		head:        "stats",
+		base:        "main",
+		labels:      []string{"backend"},
+		reviewers:   []string{"bob"},
+		checksState: "failure",
+		mergeable:   "mergeable",
+		createdAt:   time.Now().Add(-5 * 24 * time.Hour),
+		updatedAt:   time.Now().Add(-1 * time.Hour),
+		additions:   120,
+		deletions:   30,
+	}
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour).Format("2006-01-02")
+	yesterday := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"author in (alice,bob) and (checks == fail or mergeable == conflicting) and age > 3d and not label:wip", true},
+		{"author in (carol,bob)", false},
+		{"author != alice", false},
+		{"reviewer == bob and label:backend", true},
+		{"label in (wip, backend)", true},
+		{"label != backend", false},
+		{"review == pending and review != approved", true},
+		{"draft", false},
+		{"not draft", true},
+		{"draft == false", true},
+		{"size == medium and lines >= 150 and lines < 151", true},
+		{"age < 3d", false},
+		{"updated <= 2h", true},
+		{"number == 7 and base == main and head != main", true},
+		{`title == "Add stats"`, true},
+		{"checks == pass or checks == pending", false},
+
+		// Dates compare like durations, greater is longer ago
+		{"age > " + weekAgo, false},
+		{"created < " + weekAgo + " and age < 7d", true},
+		{"updated < " + yesterday, true},
+		{"updated >= " + yesterday, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			e, err := parseWhere(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := e.match(p); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileFilters(t *testing.T) {
+	where, _ := parseWhere("age > 3d or draft")
+
+	tests := []struct {
+		opts FilterOptions
+		want string
+	}{
+		{FilterOptions{}, "true"},
+		{FilterOptions{ReviewStatus: "all", Checks: "all"}, "true"},
+		{FilterOptions{Author: "-me"}, "author != me"},
+		{
+			FilterOptions{Author: "alice", Labels: []string{"bug", "-wip", "urgent"}, DraftStatus: "ready", Checks: "fail"},
+			"author == alice and label != wip and label in (bug, urgent) and draft == false and checks == fail",
+		},
+		{
+			FilterOptions{Reviewer: "bob", CreatedSince: "7d", UpdatedSince: "24h", Size: "small", Mergeable: "conflicting"},
+			"reviewer == bob and age <= 7d and updated <= 24h and size == small and mergeable == conflicting",
+		},
+		{FilterOptions{ReviewStatus: "approved", Where: where}, "review == approved and (age > 3d or draft == true)"},
+		{FilterOptions{ReviewStatus: "fully-approved", MinApprovals: 2}, "review == fully-approved and approvals >= 2"},
+		{
+			FilterOptions{CreatedSince: "2026-10-01", UpdatedBefore: "14d", CreatedBefore: "2026-10-19"},
+			"created <= 2026-10-01 and created > 2026-10-19 and updated > 14d",
+		},
+		{
+			FilterOptions{Author: "alice,bob,-carol", Labels: []string{"backend", "ready"}, LabelsMode: "all", Reviewer: "dave,-erin"},
+			"author != carol and author in (alice, bob) and label == backend and label == ready and reviewer != erin and reviewer == dave",
+		},
+		{FilterOptions{Title: "re:(?i)wip", Head: "3217-*", Base: "-main"}, `title == "re:(?i)wip" and head == 3217-* and base != main`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := compileFilters(tt.opts)
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+
+			// Flags compile to what the equivalent --where parses to
+			if tt.want == "true" {
+				return
+			}
+			parsed, err := parseWhere(tt.want)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.String() != got.String() {
+				t.Errorf("expected %q to parse to the same expression, got %q", tt.want, parsed.String())
+			}
+		})
+	}
+}
+
+func TestCompiledPatterns(t *testing.T) {
+	e, err := parseWhere(`title == "feat*" or head in (fix-*, "re:^wip")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Patterns are compiled when parsing rather than for every PR
+	for _, x := range e.(orExpr) {
+		c := x.(cmpExpr)
+		if len(c.patterns) != len(c.values) {
+			t.Errorf("expected %s to have compiled patterns, got %v", c, c.patterns)
+		}
+	}
+
+	c := compileFilters(FilterOptions{Title: "-draft*"}).(cmpExpr)
+	if len(c.patterns) != 1 || c.patterns[0].String() != "^draft.*$" {
+		t.Errorf("expected compiled --title pattern, got %v", c.patterns)
+	}
+
+	// Hand built expressions still match
+	if !(cmpExpr{field: "title", op: "==", values: []string{"feat*"}}).match(pr{title: "feature"}) {
+		t.Error("expected uncompiled pattern to match")
+	}
+}
+
+func TestFilterOptions_Compile(t *testing.T) {
+	opts := FilterOptions{Author: "alice", ChainAll: &FilterOptions{Checks: "pass"}}.compile()
+	if opts.compiled == nil || opts.ChainAll.compiled == nil {
+		t.Fatalf("expected compiled filters, got %+v", opts)
+	}
+
+	for _, p := range []pr{{author: "alice"}, {author: "bob"}} {
+		if got, want := ApplyPRFilters(p, opts), compileFilters(opts).match(p); got != want {
+			t.Errorf("compiled filters disagree for %s: %v, want %v", p.author, got, want)
+		}
+	}
+}
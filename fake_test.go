@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+var repoQueryRe = regexp.MustCompile(`repository\(owner: "([^"]+)", name: "([^"]+)"\)`)
+
+// fakeGitHub is a stand-in for the GitHub GraphQL API. Responses are
+// read from testdata/github/<owner>/<name>.json, so adding a scenario
+// only needs a new fixture file.
+type fakeGitHub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests int
+	// failures is the number of requests to answer with a 502 before
+	// serving fixtures, for exercising retries.
+	failures int
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+
+	f := &fakeGitHub{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeGitHub) setFailures(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = n
+}
+
+func (f *fakeGitHub) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests++
+	fail := f.failures > 0
+	if fail {
+		f.failures--
+	}
+	f.mu.Unlock()
+
+	if fail {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Authorization") == "" {
+		http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matches := repoQueryRe.FindStringSubmatch(body.Query)
+	if matches == nil {
+		http.Error(w, "query does not select a repository", http.StatusBadRequest)
+		return
+	}
+
+	bts, err := os.ReadFile(filepath.Join("testdata", "github", matches[1], matches[2]+".json"))
+	if err != nil {
+		fmt.Fprintf(w,
+			`{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "path": ["repository"], "message": "Could not resolve to a Repository with the name '%s/%s'."}]}`,
+			matches[1], matches[2])
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bts)
+}
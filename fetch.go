@@ -16,6 +16,10 @@ import (
 
 const githubURL = "https://api.github.com/graphql"
 
+// retryBackoff is the initial delay between retries of a failed fetch.
+// It doubles after each attempt.
+var retryBackoff = time.Second
+
 //go:embed request.graphql
 var request string
 
@@ -25,7 +29,8 @@ type pr struct {
 	head                string
 	title               string
 	author              string
-	approvedBy          string
+	approvers           []string // from their latest approving or change requesting reviews
+	requiredApprovals   int      // from the base branch's protection, 0 if none
 	hasChangesRequested bool
 	hasComments         bool
 	labels              []string
@@ -33,6 +38,8 @@ type pr struct {
 	createdAt           time.Time
 	updatedAt           time.Time
 	mergeable           string
+	mergeState          string // clean, blocked, behind, unstable, dirty, draft, has_hooks or unknown
+	reviewDecision      string // approved, changes_requested, review_required or empty
 	checksState         string
 	reviewers           []string
 	additions           int
@@ -50,17 +57,58 @@ type data struct {
 	prs           map[int]pr
 	branch        map[string]int
 	mappings      map[int]mapping
+	problems      []problem
+}
+
+// Provider fetches the raw GraphQL response listing the open PRs of
+// a repository. The response is decoded into a Response by getData.
+type Provider interface {
+	Fetch(ctx context.Context, org, repo string) ([]byte, error)
+}
+
+// githubProvider fetches data from the GitHub GraphQL API, or from any
+// server speaking the same protocol when CHAINLINK_API_URL is set.
+type githubProvider struct {
+	url      string
+	tokenEnv string // env var with the token, CHAINLINK_TOKEN if empty
+}
+
+// newGitHubProvider creates a provider for a host. CHAINLINK_API_URL
+// takes precedence over the url from the host's settings.
+func newGitHubProvider(host HostConfig) githubProvider {
+	url := os.Getenv("CHAINLINK_API_URL")
+	if len(url) == 0 {
+		url = host.APIURL
+	}
+	if len(url) == 0 {
+		url = githubURL
+	}
+
+	return githubProvider{url: url, tokenEnv: host.TokenEnv}
+}
+
+func (g githubProvider) Fetch(ctx context.Context, org, repo string) ([]byte, error) {
+	token, err := getToken(g.tokenEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchData(ctx, g.url, token, org, repo)
 }
 
 const CACHE_DIR_BASE = "/tmp/chainlink" // TODO: make cross platform
 
-func getToken() (string, error) {
-	token := os.Getenv("CHAINLINK_TOKEN")
+func getToken(env string) (string, error) {
+	if len(env) == 0 {
+		env = "CHAINLINK_TOKEN"
+	}
+
+	token := os.Getenv(env)
 	if len(token) > 0 {
 		return token, nil
 	}
 
-	return "", fmt.Errorf("missing GitHub token in CHAINLINK_TOKEN")
+	return "", fmt.Errorf("missing GitHub token in %s", env)
 }
 
 func cacheFilePath(org, repo string) string {
@@ -96,13 +144,13 @@ func writeCache(org, repo string, bts []byte) {
 	}
 }
 
-func fetchData(org, repo string) ([]byte, error) {
+func fetchData(ctx context.Context, url, token, org, repo string) ([]byte, error) {
 	const maxAttempts = 4
-	backoff := time.Second
+	backoff := retryBackoff
 
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		bts, status, err := tryFetch(org, repo)
+		bts, status, err := tryFetch(ctx, url, token, org, repo)
 		if err == nil && status == http.StatusOK {
 			return bts, nil
 		}
@@ -119,14 +167,18 @@ func fetchData(org, repo string) ([]byte, error) {
 		}
 
 		fmt.Fprintf(os.Stderr, "transient error (attempt %d/%d): %v; retrying in %s\n", attempt, maxAttempts, lastErr, backoff)
-		time.Sleep(backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
 		backoff *= 2
 	}
 	return nil, lastErr
 }
 
-func tryFetch(org, repo string) ([]byte, int, error) {
-	resp, err := makeRequest(org, repo)
+func tryFetch(ctx context.Context, url, token, org, repo string) ([]byte, int, error) {
+	resp, err := makeRequest(ctx, url, token, org, repo)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -137,7 +189,7 @@ func tryFetch(org, repo string) ([]byte, int, error) {
 	return buf.Bytes(), resp.StatusCode, nil
 }
 
-func makeRequest(org string, repo string) (*http.Response, error) {
+func makeRequest(ctx context.Context, url, token, org, repo string) (*http.Response, error) {
 	fmt.Fprintf(os.Stderr, "Fetching data for %s/%s...\r", org, repo)
 	defer func() { fmt.Fprint(os.Stderr, "\x1b[2K") }()
 
@@ -145,12 +197,7 @@ func makeRequest(org string, repo string) (*http.Response, error) {
 	body := fmt.Sprintf(`{"query": "%s"}`, strings.ReplaceAll(strings.ReplaceAll(gql, `"`, `\"`), "\n", "\\n"))
 	bodyReader := bytes.NewReader([]byte(body))
 
-	req, err := http.NewRequest(http.MethodPost, githubURL, bodyReader)
-	if err != nil {
-		return nil, err
-	}
-
-	token, err := getToken()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +208,23 @@ func makeRequest(org string, repo string) (*http.Response, error) {
 	return client.Do(req)
 }
 
-func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.Duration) (data, error) {
+// requiredApprovals is the number of approvals a branch protection rule
+// asks for
+func requiredApprovals(rule *BranchProtectionRule) int {
+	if rule == nil || !rule.RequiresApprovingReviews {
+		return 0
+	}
+	return rule.RequiredApprovingReviewCount
+}
+
+// fullyApproved is true for PRs with as many approvals as the branch
+// protection requires, or at least one if it doesn't, and no requested
+// changes
+func fullyApproved(p pr) bool {
+	return !p.hasChangesRequested && len(p.approvers) >= max(p.requiredApprovals, 1)
+}
+
+func getData(ctx context.Context, provider Provider, org, repo string, cache bool, cacheTime time.Duration) (data, error) {
 	d := data{
 		prs:      map[int]pr{},
 		branch:   map[string]int{},
@@ -175,7 +238,7 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 	}
 	if !fromCache {
 		var err error
-		response, err = fetchData(org, repo)
+		response, err = provider.Fetch(ctx, org, repo)
 		if err != nil {
 			return d, err
 		}
@@ -194,8 +257,11 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 		return d, fmt.Errorf("unable to fetch PRs")
 	}
 
-	// Only cache after confirming the response is valid
-	if !fromCache {
+	// Only cache after confirming the response is valid. Replayed
+	// responses never touch the cache so that they can't leak into
+	// later runs against the real API.
+	_, replay := provider.(replayProvider)
+	if !fromCache && !replay {
 		writeCache(org, repo, response)
 	}
 
@@ -203,13 +269,32 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 	d.url = resp.Data.Repository.URL
 	d.branch[resp.Data.Repository.DefaultBranchRef.Name] = 0
 
+	// Stacked PRs usually target unprotected branches but will end up
+	// in the default branch, so its rule applies when theirs doesn't
+	defaultRequired := requiredApprovals(resp.Data.Repository.DefaultBranchRef.BranchProtectionRule)
+
 	for _, p := range resp.Data.Repository.PullRequests.Edges {
 		n := p.Node
 
-		aby := ""
+		approvers := []string{}
 		hasChangesRequested := false
 		hasComments := false
 
+		// Approvals and change requests count like they do on GitHub,
+		// where a comment doesn't undo an earlier approval
+		for _, review := range n.LatestOpinionatedReviews.Nodes {
+			login := review.Author.Login
+			if login == "" {
+				continue
+			}
+			switch review.State {
+			case "APPROVED":
+				approvers = append(approvers, login)
+			case "CHANGES_REQUESTED":
+				hasChangesRequested = true
+			}
+		}
+
 		// Track latest review state per user (last review wins)
 		latestReview := make(map[string]string)
 		for _, review := range n.Reviews.Edges {
@@ -218,15 +303,8 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 				latestReview[login] = review.Node.State
 			}
 		}
-		for login, state := range latestReview {
-			switch state {
-			case "APPROVED":
-				if aby == "" {
-					aby = login
-				}
-			case "CHANGES_REQUESTED":
-				hasChangesRequested = true
-			case "COMMENTED":
+		for _, state := range latestReview {
+			if state == "COMMENTED" {
 				hasComments = true
 			}
 		}
@@ -258,6 +336,11 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 		createdAt, _ := time.Parse(time.RFC3339, n.CreatedAt)
 		updatedAt, _ := time.Parse(time.RFC3339, n.UpdatedAt)
 
+		required := defaultRequired
+		if n.BaseRef != nil && n.BaseRef.BranchProtectionRule != nil {
+			required = requiredApprovals(n.BaseRef.BranchProtectionRule)
+		}
+
 		checksState := ""
 		if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
 			checksState = strings.ToLower(n.Commits.Nodes[0].Commit.StatusCheckRollup.State)
@@ -269,7 +352,8 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 			base:                n.BaseRefName,
 			author:              n.Author.Login,
 			title:               n.Title,
-			approvedBy:          aby,
+			approvers:           approvers,
+			requiredApprovals:   required,
 			hasChangesRequested: hasChangesRequested,
 			hasComments:         hasComments,
 			labels:              labels,
@@ -277,6 +361,8 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 			createdAt:           createdAt,
 			updatedAt:           updatedAt,
 			mergeable:           strings.ToLower(n.Mergeable),
+			mergeState:          strings.ToLower(n.MergeStateStatus),
+			reviewDecision:      strings.ToLower(n.ReviewDecision),
 			checksState:         checksState,
 			reviewers:           reviewers,
 			additions:           n.Additions,
@@ -284,7 +370,12 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 		}
 
 		d.prs[n.Number] = mpr
-		d.branch[n.HeadRefName] = n.Number
+
+		// A PR (usually from a fork) using the default branch as
+		// its head must not take over the root of every chain.
+		if n.HeadRefName != d.defaultBranch {
+			d.branch[n.HeadRefName] = n.Number
+		}
 	}
 
 	// Register base branches that aren't already tracked (e.g. from
@@ -330,5 +421,7 @@ func getData(ctx context.Context, org, repo string, cache bool, cacheTime time.D
 		}
 	}
 
+	validateGraph(&d, resp)
+
 	return d, nil
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetData_FromFakeServer(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	f := newFakeGitHub(t)
+
+	d, err := getData(context.Background(), githubProvider{url: f.URL}, "test", "repo", false, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d.url != "https://github.com/test/repo" {
+		t.Errorf("unexpected url %q", d.url)
+	}
+	if d.defaultBranch != "main" {
+		t.Errorf("unexpected default branch %q", d.defaultBranch)
+	}
+	if len(d.prs) != 6 {
+		t.Fatalf("expected 6 PRs, got %d", len(d.prs))
+	}
+
+	p := d.prs[11]
+	if p.base != "cleanup" || p.head != "model-mod-time" {
+		t.Errorf("unexpected branches %q <- %q", p.base, p.head)
+	}
+	if !p.hasChangesRequested {
+		t.Error("expected changes requested on #11")
+	}
+	if p.checksState != "failure" {
+		t.Errorf("expected failing checks, got %q", p.checksState)
+	}
+	if !slices.Equal(p.reviewers, []string{"bob", "carol"}) {
+		t.Errorf("unexpected reviewers %v", p.reviewers)
+	}
+	if !slices.Equal(d.prs[10].approvers, []string{"bob", "carol"}) {
+		t.Errorf("expected #10 approved by bob and carol, got %v", d.prs[10].approvers)
+	}
+	// bob commented on #20 after approving it, which still counts
+	if !slices.Equal(d.prs[20].approvers, []string{"bob"}) || !d.prs[20].hasComments {
+		t.Errorf("expected #20 approved by bob with comments, got %v", d.prs[20].approvers)
+	}
+	if d.prs[10].requiredApprovals != 2 || d.prs[20].requiredApprovals != 2 {
+		t.Errorf("expected 2 required approvals from the default branch, got %d", d.prs[10].requiredApprovals)
+	}
+	if !fullyApproved(d.prs[10]) || fullyApproved(d.prs[20]) {
+		t.Errorf("expected only #10 to be fully approved, got %v and %v", d.prs[10].approvers, d.prs[20].approvers)
+	}
+	if d.prs[12].mergeState != "dirty" || d.prs[20].reviewDecision != "review_required" {
+		t.Errorf("unexpected merge state %q or review decision %q", d.prs[12].mergeState, d.prs[20].reviewDecision)
+	}
+	if d.prs[12].mergeable != "conflicting" {
+		t.Errorf("expected #12 conflicting, got %q", d.prs[12].mergeable)
+	}
+	if !d.prs[10].createdAt.Equal(time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected createdAt %v", d.prs[10].createdAt)
+	}
+
+	if !slices.Equal(d.mappings[0].following, []int{10, 20, 30}) {
+		t.Errorf("unexpected roots %v", d.mappings[0].following)
+	}
+	if !slices.Equal(d.mappings[10].following, []int{11}) {
+		t.Errorf("unexpected children of #10 %v", d.mappings[10].following)
+	}
+	if d.mappings[12].base != 11 {
+		t.Errorf("expected #12 based on #11, got %d", d.mappings[12].base)
+	}
+}
+
+func TestGetData_RetriesTransientErrors(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	defer func(b time.Duration) { retryBackoff = b }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	f := newFakeGitHub(t)
+	f.setFailures(2)
+
+	d, err := getData(context.Background(), githubProvider{url: f.URL}, "test", "repo", false, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.prs) != 6 {
+		t.Errorf("expected 6 PRs, got %d", len(d.prs))
+	}
+	if f.requestCount() != 3 {
+		t.Errorf("expected 3 requests, got %d", f.requestCount())
+	}
+}
+
+func TestGetData_GivesUpAfterMaxAttempts(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	defer func(b time.Duration) { retryBackoff = b }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	f := newFakeGitHub(t)
+	f.setFailures(10)
+
+	_, err := getData(context.Background(), githubProvider{url: f.URL}, "test", "repo", false, 0)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if f.requestCount() != 4 {
+		t.Errorf("expected 4 requests, got %d", f.requestCount())
+	}
+}
+
+func TestGetData_CancelledWhileWaiting(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	defer func(b time.Duration) { retryBackoff = b }(retryBackoff)
+	retryBackoff = time.Hour
+
+	f := newFakeGitHub(t)
+	f.setFailures(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := getData(ctx, githubProvider{url: f.URL}, "test", "repo", false, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to be cut short, got %v", err)
+	}
+	if f.requestCount() != 1 {
+		t.Errorf("expected 1 request, got %d", f.requestCount())
+	}
+}
+
+func TestGetData_GraphQLErrors(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	f := newFakeGitHub(t)
+
+	_, err := getData(context.Background(), githubProvider{url: f.URL}, "test", "missing", false, 0)
+	if err == nil {
+		t.Fatal("expected error for missing repository")
+	}
+}
+
+func TestNewGitHubProvider_APIURL(t *testing.T) {
+	t.Setenv("CHAINLINK_API_URL", "")
+	if p := newGitHubProvider(HostConfig{}); p.url != githubURL {
+		t.Errorf("expected default url, got %q", p.url)
+	}
+
+	host := HostConfig{APIURL: "https://github.example.com/api/graphql", TokenEnv: "GHE_TOKEN"}
+	if p := newGitHubProvider(host); p.url != host.APIURL || p.tokenEnv != "GHE_TOKEN" {
+		t.Errorf("expected host settings to be used, got %+v", p)
+	}
+
+	t.Setenv("CHAINLINK_API_URL", "http://localhost:1234/graphql")
+	if p := newGitHubProvider(host); p.url != "http://localhost:1234/graphql" {
+		t.Errorf("expected override url, got %q", p.url)
+	}
+}
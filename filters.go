@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
@@ -37,167 +38,383 @@ func parseDuration(s string) (time.Duration, error) {
 	}
 }
 
-// FilterOptions contains all possible filter parameters
-type FilterOptions struct {
-	Author       string
-	ReviewStatus string
-	Labels       []string
-	Reviewer     string
-	DraftStatus  string // "draft", "ready", "all"
-	Size         string // "small", "medium", "large", "all"
-	Mergeable    string // "mergeable", "conflicting", "all"
-	Checks       string // "pass", "fail", "pending", "all"
-	UpdatedSince string // "24h", "7d", etc.
-	CreatedSince string // "24h", "7d", etc.
+// parseDate parses an absolute date like 2026-10-01, in local time, or
+// an RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
 }
 
-// ApplyPRFilters filters a PR based on the given options
-func ApplyPRFilters(pr pr, opts FilterOptions) bool {
-	// Apply author filter (prefix with - to exclude)
-	if len(opts.Author) != 0 {
-		if strings.HasPrefix(opts.Author, "-") {
-			if pr.author == opts.Author[1:] {
-				return false
+// parseTimeValue parses the value of a time filter, which is either a
+// duration ago or a date. Durations are returned as nanoseconds and
+// dates as unix nanoseconds.
+func parseTimeValue(s string) (float64, bool, error) {
+	if d, err := parseDuration(s); err == nil {
+		return float64(d), false, nil
+	}
+
+	t, err := parseDate(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("expected a duration like 7d or a date like 2026-10-01")
+	}
+	return float64(t.UnixNano()), true, nil
+}
+
+// compilePattern turns a --title, --head or --base pattern into a
+// regular expression. Patterns are globs matching the whole value unless
+// prefixed with re:, in which case they are regular expressions which
+// can match anywhere.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		return regexp.Compile(expr)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %q", pattern)
 			}
-		} else if pr.author != opts.Author {
-			return false
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
 		}
 	}
+	b.WriteString("$")
 
-	// Apply review status filter
-	switch opts.ReviewStatus {
-	case "approved":
-		if len(pr.approvedBy) == 0 {
-			return false
+	return regexp.Compile(b.String())
+}
+
+// splitList splits a comma-separated list, as accepted by --author and
+// --reviewer
+func splitList(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// expandTeams replaces @name entries of a comma-separated list of users
+// with the members of that team from the config, keeping the - prefix
+// for excluded ones.
+func expandTeams(s string, teams map[string][]string) (string, error) {
+	users := []string{}
+	for _, u := range splitList(s) {
+		prefix := ""
+		if strings.HasPrefix(u, "-") {
+			prefix, u = "-", u[1:]
 		}
-	case "pending":
-		if len(pr.approvedBy) > 0 {
-			return false
+
+		name, ok := strings.CutPrefix(u, "@")
+		if !ok {
+			users = append(users, prefix+u)
+			continue
 		}
-	case "unapproved":
-		if len(pr.approvedBy) > 0 {
-			return false
+
+		members, ok := teams[name]
+		if !ok {
+			return "", fmt.Errorf("unknown team %q", name)
 		}
-	case "changes-requested":
-		if !pr.hasChangesRequested {
-			return false
+		// An empty list would match everyone rather than no one
+		if len(members) == 0 {
+			return "", fmt.Errorf("team %q has no members", name)
+		}
+		for _, m := range members {
+			users = append(users, prefix+m)
 		}
 	}
 
-	// Apply labels filter (prefix with - to exclude)
-	if len(opts.Labels) > 0 {
-		includeLabels := []string{}
-		excludeLabels := []string{}
-		for _, l := range opts.Labels {
-			if strings.HasPrefix(l, "-") {
-				excludeLabels = append(excludeLabels, l[1:])
-			} else {
-				includeLabels = append(includeLabels, l)
-			}
-		}
+	return strings.Join(users, ","), nil
+}
 
-		// Check exclude labels: PR must not have any of these
-		for _, el := range excludeLabels {
-			if slices.Contains(pr.labels, el) {
-				return false
-			}
-		}
+// FilterOptions contains all possible filter parameters
+type FilterOptions struct {
+	Author        string // comma-separated, prefix with - to exclude
+	ReviewStatus  string
+	MinApprovals  int
+	Labels        []string
+	LabelsMode    string // "any" or "all" of the included labels
+	Reviewer      string // comma-separated, prefix with - to exclude
+	DraftStatus   string // "draft", "ready", "all"
+	Size          string // "small", "medium", "large", "all"
+	Mergeable     string // "mergeable", "conflicting", "all"
+	MergeState    string // "clean", "blocked", "behind", "unstable", "dirty", "all"
+	Checks        string // "pass", "fail", "pending", "all"
+	UpdatedSince  string // "24h", "7d", "2026-10-01", etc.
+	CreatedSince  string // "24h", "7d", "2026-10-01", etc.
+	UpdatedBefore string // "24h", "7d", "2026-10-01", etc.
+	CreatedBefore string // "24h", "7d", "2026-10-01", etc.
+	Title         string // glob or re: regex, prefix with - to exclude
+	Head          string
+	Base          string
+	Where         filterExpr // parsed --where expression
 
-		// Check include labels: PR must have at least one of these
-		if len(includeLabels) > 0 {
-			hasLabel := false
-			for _, wantLabel := range includeLabels {
-				if slices.Contains(pr.labels, wantLabel) {
-					hasLabel = true
-					break
-				}
-			}
-			if !hasLabel {
-				return false
-			}
+	// Chain filters keep or drop whole chains, see filterWholeChains
+	ChainAll *FilterOptions // every PR in the chain has to match
+	ChainAny *FilterOptions // at least one PR in the chain has to match
+	MinDepth int
+	MaxDepth int
+
+	compiled filterExpr // set by compile
+}
+
+// compile compiles the filters once for a command instead of for every
+// PR they are applied to. It has to be called again after changing the
+// options, otherwise the old filters keep being used.
+func (o FilterOptions) compile() FilterOptions {
+	for _, chain := range []**FilterOptions{&o.ChainAll, &o.ChainAny} {
+		if *chain != nil {
+			c := (*chain).compile()
+			*chain = &c
 		}
 	}
 
-	// Apply reviewer filter
-	if len(opts.Reviewer) > 0 {
-		hasReviewer := slices.Contains(pr.reviewers, opts.Reviewer)
-		if !hasReviewer {
-			return false
+	o.compiled = nil
+	o.compiled = compileFilters(o)
+	return o
+}
+
+// ApplyPRFilters filters a PR based on the given options
+func ApplyPRFilters(pr pr, opts FilterOptions) bool {
+	if opts.compiled != nil {
+		return opts.compiled.match(pr)
+	}
+	return compileFilters(opts).match(pr)
+}
+
+// FilterPRNumbers filters a slice of PR numbers based on the given options
+func FilterPRNumbers(d data, prNumbers []int, opts FilterOptions) []int {
+	filtered := []int{}
+	for _, num := range prNumbers {
+		if ApplyPRFilters(d.prs[num], opts) {
+			filtered = append(filtered, num)
 		}
 	}
+	return filtered
+}
 
-	// Apply draft status filter
-	switch opts.DraftStatus {
-	case "draft":
-		if !pr.isDraft {
-			return false
+// filterKeys maps the keys accepted by parseFilterString to the values
+// they allow. An empty list means any value. They mirror the filter
+// flags of log, with --where being the filter text as a whole, see
+// parseTUIFilter.
+var filterKeys = map[string][]string{
+	"author":         nil,
+	"reviewer":       nil,
+	"labels":         nil,
+	"labels-mode":    {"any", "all"},
+	"review":         {"approved", "fully-approved", "pending", "unapproved", "changes-requested", "all"},
+	"min-approvals":  nil,
+	"status":         {"ready", "blocked"},
+	"blocked-by":     blockReasonNames(),
+	"stale":          {"true", "false"},
+	"min-depth":      nil,
+	"max-depth":      nil,
+	"draft":          {"draft", "ready", "all"},
+	"size":           {"small", "medium", "large", "all"},
+	"mergeable":      {"mergeable", "conflicting", "all"},
+	"merge-state":    {"clean", "blocked", "behind", "unstable", "dirty", "all"},
+	"checks":         {"pass", "fail", "pending", "all"},
+	"updated":        nil,
+	"created":        nil,
+	"updated-before": nil,
+	"created-before": nil,
+	"title":          nil,
+	"head":           nil,
+	"base":           nil,
+}
+
+// parseFilterString parses filters written as space separated
+// key=value pairs, e.g. "author=-me checks=fail labels=bug,-wip".
+func parseFilterString(s string) (FilterOptions, error) {
+	opts := FilterOptions{}
+	for _, field := range strings.Fields(s) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return opts, fmt.Errorf("expected key=value, got %q", field)
 		}
-	case "ready":
-		if pr.isDraft {
-			return false
+
+		allowed, known := filterKeys[key]
+		if !known {
+			return opts, fmt.Errorf("unknown filter %q", key)
+		}
+		if len(allowed) > 0 && !slices.Contains(allowed, value) {
+			return opts, fmt.Errorf("invalid %s %q (%s)", key, value, strings.Join(allowed, ","))
 		}
-	}
 
-	// Apply created-since filter
-	if len(opts.CreatedSince) > 0 {
-		duration, err := parseDuration(opts.CreatedSince)
-		if err == nil {
-			if time.Since(pr.createdAt) > duration {
-				return false
+		switch key {
+		case "author":
+			opts.Author = value
+		case "reviewer":
+			opts.Reviewer = value
+		case "labels":
+			opts.Labels = strings.Split(value, ",")
+		case "labels-mode":
+			opts.LabelsMode = value
+		case "review":
+			opts.ReviewStatus = value
+		case "min-approvals", "min-depth", "max-depth":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("invalid %s %q, expected a number", key, value)
+			}
+			switch key {
+			case "min-approvals":
+				opts.MinApprovals = n
+			case "min-depth":
+				opts.MinDepth = n
+			default:
+				opts.MaxDepth = n
+			}
+		case "status", "blocked-by":
+			// There are no flags for these, only --where fields
+			c := cmpExpr{field: key, op: "==", values: []string{value}}
+			if opts.Where == nil {
+				opts.Where = c
+			} else {
+				opts.Where = andExpr{opts.Where, c}
+			}
+		case "stale":
+			if value == "true" {
+				opts.UpdatedBefore = staleAfter
+			}
+		case "draft":
+			opts.DraftStatus = value
+		case "size":
+			opts.Size = value
+		case "mergeable":
+			opts.Mergeable = value
+		case "merge-state":
+			opts.MergeState = value
+		case "checks":
+			opts.Checks = value
+		case "updated", "created", "updated-before", "created-before":
+			if _, _, err := parseTimeValue(value); err != nil {
+				return opts, fmt.Errorf("invalid %s %q, %v", key, value, err)
+			}
+			switch key {
+			case "updated":
+				opts.UpdatedSince = value
+			case "created":
+				opts.CreatedSince = value
+			case "updated-before":
+				opts.UpdatedBefore = value
+			default:
+				opts.CreatedBefore = value
+			}
+		case "title", "head", "base":
+			if _, err := compilePattern(strings.TrimPrefix(value, "-")); err != nil {
+				return opts, fmt.Errorf("invalid %s pattern %q: %v", key, value, err)
+			}
+			switch key {
+			case "title":
+				opts.Title = value
+			case "head":
+				opts.Head = value
+			default:
+				opts.Base = value
 			}
 		}
 	}
 
-	// Apply updated-since filter
-	if len(opts.UpdatedSince) > 0 {
-		duration, err := parseDuration(opts.UpdatedSince)
-		if err == nil {
-			if time.Since(pr.updatedAt) > duration {
-				return false
-			}
-		}
+	return opts, nil
+}
+
+// parseTUIFilter parses the filter of the TUI, which is either key=value
+// pairs as accepted by parseFilterString or a --where expression
+func parseTUIFilter(s string) (FilterOptions, error) {
+	opts, err := parseFilterString(s)
+	if err == nil {
+		return opts, nil
 	}
 
-	// Apply size filter
-	switch opts.Size {
-	case "small":
-		if pr.additions+pr.deletions > 100 {
-			return false
-		}
-	case "medium":
-		if pr.additions+pr.deletions <= 100 || pr.additions+pr.deletions > 500 {
-			return false
-		}
-	case "large":
-		if pr.additions+pr.deletions <= 500 {
-			return false
-		}
+	where, whereErr := parseWhere(s)
+	if whereErr == nil {
+		return FilterOptions{Where: where}, nil
 	}
 
-	// Apply mergeable filter
-	switch opts.Mergeable {
-	case "mergeable":
-		if pr.mergeable != "mergeable" {
-			return false
+	// Report the error of the syntax that was most likely meant, on a
+	// single line as it goes in the status bar
+	for _, field := range strings.Fields(s) {
+		var we whereError
+		if !strings.Contains(field, "=") && errors.As(whereErr, &we) {
+			return opts, fmt.Errorf("%s at column %d", we.msg, we.pos+1)
 		}
-	case "conflicting":
-		if pr.mergeable != "conflicting" {
-			return false
+	}
+	return opts, err
+}
+
+// chainFilterKeys are the keys allowed in --chain-all and --chain-any
+var chainFilterKeys = []string{"review", "checks", "mergeable", "merge-state", "draft"}
+
+// parseChainFilter parses the key=value pairs of --chain-all or
+// --chain-any. It returns nil if there is nothing to filter on.
+func parseChainFilter(s string) (*FilterOptions, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	for _, field := range strings.Fields(s) {
+		key, _, ok := strings.Cut(field, "=")
+		if ok && !slices.Contains(chainFilterKeys, key) {
+			return nil, fmt.Errorf("unknown chain filter %q (%s)", key, strings.Join(chainFilterKeys, ","))
 		}
 	}
 
-	// Apply checks filter
-	switch opts.Checks {
-	case "pass":
-		if pr.checksState != "success" {
-			return false
+	opts, err := parseFilterString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// chainDepth is the number of PRs on the longest path through the
+// chain made of the given PRs
+func chainDepth(mappings map[int]mapping, prns []int) int {
+	depth := 0
+	for _, n := range prns {
+		levels := 1
+		for base := mappings[n].base; slices.Contains(prns, base); base = mappings[base].base {
+			levels++
 		}
-	case "fail":
-		if pr.checksState != "failure" && pr.checksState != "error" {
-			return false
+		depth = max(depth, levels)
+	}
+	return depth
+}
+
+// chainMatches checks the PRs of a chain against the chain filters
+func chainMatches(d data, mappings map[int]mapping, prns []int, opts FilterOptions) bool {
+	if opts.ChainAll != nil {
+		for _, n := range prns {
+			if !ApplyPRFilters(d.prs[n], *opts.ChainAll) {
+				return false
+			}
 		}
-	case "pending":
-		if pr.checksState != "pending" && pr.checksState != "expected" {
+	}
+
+	if opts.ChainAny != nil && !slices.ContainsFunc(prns, func(n int) bool {
+		return ApplyPRFilters(d.prs[n], *opts.ChainAny)
+	}) {
+		return false
+	}
+
+	if opts.MinDepth > 0 || opts.MaxDepth > 0 {
+		depth := chainDepth(mappings, prns)
+		if depth < opts.MinDepth || (opts.MaxDepth > 0 && depth > opts.MaxDepth) {
 			return false
 		}
 	}
@@ -205,13 +422,40 @@ func ApplyPRFilters(pr pr, opts FilterOptions) bool {
 	return true
 }
 
-// FilterPRNumbers filters a slice of PR numbers based on the given options
-func FilterPRNumbers(d data, prNumbers []int, opts FilterOptions) []int {
-	filtered := []int{}
-	for _, num := range prNumbers {
-		if ApplyPRFilters(d.prs[num], opts) {
-			filtered = append(filtered, num)
+// chainPRs returns the PRs of the chain starting at root
+func chainPRs(m map[int]mapping, root int) []int {
+	prns := []int{}
+	stack := []int{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		prns = append(prns, n)
+		stack = append(stack, m[n].following...)
+	}
+	return prns
+}
+
+// filterWholeChains drops the chains which don't match the chain
+// filters. Unlike the PR filters, which promote matching descendants,
+// a chain is either kept as a whole or not at all.
+func filterWholeChains(d data, m map[int]mapping, opts FilterOptions) map[int]mapping {
+	if opts.ChainAll == nil && opts.ChainAny == nil && opts.MinDepth == 0 && opts.MaxDepth == 0 {
+		return m
+	}
+
+	nm := make(map[int]mapping, len(m))
+	for k, v := range m {
+		nm[k] = v
+	}
+
+	roots := []int{}
+	for _, root := range m[0].following {
+		if chainMatches(d, m, chainPRs(m, root), opts) {
+			roots = append(roots, root)
 		}
 	}
-	return filtered
+
+	nm[0] = mapping{following: roots}
+
+	return nm
 }
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/alecthomas/kong"
 )
 
 func TestApplyPRFilters_Author(t *testing.T) {
@@ -18,6 +23,10 @@ func TestApplyPRFilters_Author(t *testing.T) {
 		{"non-matching author", "bob", false},
 		{"exclude matching author", "-alice", false},
 		{"exclude non-matching author", "-bob", true},
+		{"one of several authors", "bob,alice", true},
+		{"none of several authors", "bob,carol", false},
+		{"exclude several authors", "-bob,-alice", false},
+		{"include and exclude", "alice,-bob", true},
 	}
 
 	for _, tt := range tests {
@@ -31,9 +40,11 @@ func TestApplyPRFilters_Author(t *testing.T) {
 }
 
 func TestApplyPRFilters_ReviewStatus(t *testing.T) {
-	approved := pr{number: 1, approvedBy: "bob"}
+	approved := pr{number: 1, approvers: []string{"bob"}}
 	pending := pr{number: 2}
 	changesReq := pr{number: 3, hasChangesRequested: true}
+	partial := pr{number: 4, approvers: []string{"bob"}, requiredApprovals: 2}
+	full := pr{number: 5, approvers: []string{"bob", "carol"}, requiredApprovals: 2}
 
 	tests := []struct {
 		name   string
@@ -49,6 +60,10 @@ func TestApplyPRFilters_ReviewStatus(t *testing.T) {
 		{"approved fails unapproved", approved, "unapproved", false},
 		{"changes-requested matches", changesReq, "changes-requested", true},
 		{"no changes-requested fails", pending, "changes-requested", false},
+		{"one approval is enough without protection", approved, "fully-approved", true},
+		{"partial approval isn't full", partial, "fully-approved", false},
+		{"required approvals are full", full, "fully-approved", true},
+		{"partial approval is approved", partial, "approved", true},
 	}
 
 	for _, tt := range tests {
@@ -61,6 +76,30 @@ func TestApplyPRFilters_ReviewStatus(t *testing.T) {
 	}
 }
 
+func TestApplyPRFilters_MinApprovals(t *testing.T) {
+	p := pr{number: 1, approvers: []string{"bob", "carol"}}
+
+	tests := []struct {
+		name string
+		min  int
+		want bool
+	}{
+		{"no minimum", 0, true},
+		{"below count", 1, true},
+		{"at count", 2, true},
+		{"above count", 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPRFilters(p, FilterOptions{MinApprovals: tt.min})
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestApplyPRFilters_Labels(t *testing.T) {
 	p := pr{number: 1, labels: []string{"bug", "urgent"}}
 	noLabels := pr{number: 2, labels: []string{}}
@@ -93,6 +132,69 @@ func TestApplyPRFilters_Labels(t *testing.T) {
 	}
 }
 
+func TestApplyPRFilters_LabelsMode(t *testing.T) {
+	p := pr{number: 1, labels: []string{"backend", "ready"}}
+	partial := pr{number: 2, labels: []string{"backend"}}
+
+	tests := []struct {
+		name   string
+		pr     pr
+		labels []string
+		mode   string
+		want   bool
+	}{
+		{"all labels present", p, []string{"backend", "ready"}, "all", true},
+		{"one label missing", partial, []string{"backend", "ready"}, "all", false},
+		{"any label present", partial, []string{"backend", "ready"}, "any", true},
+		{"default is any", partial, []string{"backend", "ready"}, "", true},
+		{"all with exclude", p, []string{"backend", "-ready"}, "all", false},
+		{"all with only excludes", partial, []string{"-ready"}, "all", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPRFilters(tt.pr, FilterOptions{Labels: tt.labels, LabelsMode: tt.mode})
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTeams(t *testing.T) {
+	teams := map[string][]string{
+		"backend": {"alice", "bob"},
+		"empty":   {},
+	}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"carol", "carol", false},
+		{"@backend", "alice,bob", false},
+		{"carol,@backend", "carol,alice,bob", false},
+		{"-@backend", "-alice,-bob", false},
+		{"@empty", "", true},
+		{"carol,-@empty", "", true},
+		{"@frontend", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := expandTeams(tt.input, teams)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestApplyPRFilters_Reviewer(t *testing.T) {
 	p := pr{number: 1, reviewers: []string{"alice", "bob"}}
 	noReviewers := pr{number: 2, reviewers: []string{}}
@@ -108,6 +210,10 @@ func TestApplyPRFilters_Reviewer(t *testing.T) {
 		{"non-matching reviewer", p, "charlie", false},
 		{"no reviewers on PR", noReviewers, "alice", false},
 		{"empty filter", p, "", true},
+		{"one of several reviewers", p, "charlie,bob", true},
+		{"none of several reviewers", p, "charlie,dave", false},
+		{"exclude reviewer", p, "-bob", false},
+		{"exclude non-matching reviewer", noReviewers, "-bob", true},
 	}
 
 	for _, tt := range tests {
@@ -232,6 +338,33 @@ func TestApplyPRFilters_Mergeable(t *testing.T) {
 	}
 }
 
+func TestApplyPRFilters_MergeState(t *testing.T) {
+	clean := pr{number: 1, mergeState: "clean"}
+	behind := pr{number: 2, mergeState: "behind"}
+
+	tests := []struct {
+		name  string
+		pr    pr
+		state string
+		want  bool
+	}{
+		{"clean matches clean", clean, "clean", true},
+		{"behind fails clean", behind, "clean", false},
+		{"behind matches behind", behind, "behind", true},
+		{"all matches", behind, "all", true},
+		{"empty filter", clean, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPRFilters(tt.pr, FilterOptions{MergeState: tt.state})
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestApplyPRFilters_Checks(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -287,6 +420,126 @@ func TestApplyPRFilters_UpdatedSince(t *testing.T) {
 	}
 }
 
+func TestApplyPRFilters_Before(t *testing.T) {
+	recent := pr{number: 1, createdAt: time.Now().Add(-1 * time.Hour), updatedAt: time.Now().Add(-1 * time.Hour)}
+	stale := pr{number: 2, createdAt: time.Now().Add(-60 * 24 * time.Hour), updatedAt: time.Now().Add(-20 * 24 * time.Hour)}
+	lastYear := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	tests := []struct {
+		name string
+		pr   pr
+		opts FilterOptions
+		want bool
+	}{
+		{"recent not updated before 14d", recent, FilterOptions{UpdatedBefore: "14d"}, false},
+		{"stale updated before 14d", stale, FilterOptions{UpdatedBefore: "14d"}, true},
+		{"stale created before 30d", stale, FilterOptions{CreatedBefore: "30d"}, true},
+		{"recent created before tomorrow", recent, FilterOptions{CreatedBefore: tomorrow}, true},
+		{"stale not updated before last year", stale, FilterOptions{UpdatedBefore: lastYear}, false},
+		{"recent updated since last year", recent, FilterOptions{UpdatedSince: lastYear}, true},
+		{"recent not created since tomorrow", recent, FilterOptions{CreatedSince: tomorrow}, false},
+		{"stale in window", stale, FilterOptions{UpdatedBefore: "14d", UpdatedSince: "4w"}, true},
+		{"recent outside window", recent, FilterOptions{UpdatedBefore: "14d", UpdatedSince: "4w"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPRFilters(tt.pr, tt.opts)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantDate bool
+		wantErr  bool
+	}{
+		{"7d", false, false},
+		{"36h", false, false},
+		{"2026-10-01", true, false},
+		{"2026-10-01T10:00:00Z", true, false},
+		{"2026-13-01", false, true},
+		{"soon", false, true},
+		{"", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, date, err := parseTimeValue(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if date != tt.wantDate {
+				t.Errorf("got date %v, want %v", date, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestApplyPRFilters_Patterns(t *testing.T) {
+	p := pr{number: 1, title: "Add mod time to models", head: "3217-model/mod-time", base: "main"}
+
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want bool
+	}{
+		{"glob head", FilterOptions{Head: "3217-*"}, true},
+		{"glob head across slashes", FilterOptions{Head: "*/mod-time"}, true},
+		{"glob has to match whole value", FilterOptions{Head: "3217"}, false},
+		{"glob single char", FilterOptions{Base: "mai?"}, true},
+		{"glob class", FilterOptions{Head: "[0-9]*"}, true},
+		{"glob negated class", FilterOptions{Head: "[!0-9]*"}, false},
+		{"regex title", FilterOptions{Title: "re:mod(el)?s?"}, true},
+		{"regex case insensitive", FilterOptions{Title: "re:(?i)^add"}, true},
+		{"regex non-matching", FilterOptions{Title: "re:^Fix"}, false},
+		{"exclude base", FilterOptions{Base: "-main"}, false},
+		{"exclude other base", FilterOptions{Base: "-release-*"}, true},
+		{"exclude regex", FilterOptions{Title: "-re:WIP"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPRFilters(p, tt.opts)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{"feat-*", `^feat-.*$`, false},
+		{"v1.?", `^v1\..$`, false},
+		{"[!a-c]x", `^[^a-c]x$`, false},
+		{"re:^feat/", `^feat/`, false},
+		{"[abc", "", true},
+		{"re:(", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			re, err := compilePattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && re.String() != tt.want {
+				t.Errorf("got %s, want %s", re.String(), tt.want)
+			}
+		})
+	}
+}
+
 func TestApplyPRFilters_Combined(t *testing.T) {
 	p := pr{
 		number:    1,
@@ -406,3 +659,185 @@ func TestParseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilterString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  FilterOptions
+		err   bool
+	}{
+		{"", FilterOptions{}, false},
+		{"author=-me checks=fail", FilterOptions{Author: "-me", Checks: "fail"}, false},
+		{"labels=bug,-wip review=approved", FilterOptions{Labels: []string{"bug", "-wip"}, ReviewStatus: "approved"}, false},
+		{"author=alice,bob labels=backend,ready labels-mode=all", FilterOptions{Author: "alice,bob", Labels: []string{"backend", "ready"}, LabelsMode: "all"}, false},
+		{"updated=7d created=24h", FilterOptions{UpdatedSince: "7d", CreatedSince: "24h"}, false},
+		{"updated-before=14d created-before=2026-10-01", FilterOptions{UpdatedBefore: "14d", CreatedBefore: "2026-10-01"}, false},
+		{"draft=ready size=small mergeable=conflicting reviewer=bob", FilterOptions{DraftStatus: "ready", Size: "small", Mergeable: "conflicting", Reviewer: "bob"}, false},
+		{"min-approvals=2 stale=true min-depth=3", FilterOptions{MinApprovals: 2, UpdatedBefore: staleAfter, MinDepth: 3}, false},
+		{"status=blocked", FilterOptions{Where: cmpExpr{field: "status", op: "==", values: []string{"blocked"}}}, false},
+		{"status=blocked blocked-by=review", FilterOptions{Where: andExpr{
+			cmpExpr{field: "status", op: "==", values: []string{"blocked"}},
+			cmpExpr{field: "blocked-by", op: "==", values: []string{"review"}},
+		}}, false},
+		{"min-approvals=many", FilterOptions{}, true},
+		{"blocked-by=cats", FilterOptions{}, true},
+		{"author", FilterOptions{}, true},
+		{"colour=red", FilterOptions{}, true},
+		{"checks=green", FilterOptions{}, true},
+		{"updated=soon", FilterOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseFilterString(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTUIFilter(t *testing.T) {
+	opts, err := parseTUIFilter("author=alice checks=fail")
+	if err != nil || opts.Author != "alice" || opts.Checks != "fail" || opts.Where != nil {
+		t.Errorf("expected key=value pairs, got %+v (%v)", opts, err)
+	}
+
+	opts, err = parseTUIFilter("approvals >= 1 and not draft")
+	if err != nil || opts.Where == nil || opts.Where.String() != "approvals >= 1 and not draft == true" {
+		t.Errorf("expected a where expression, got %+v (%v)", opts, err)
+	}
+
+	_, err = parseTUIFilter("colour=red")
+	if err == nil || !strings.Contains(err.Error(), "unknown filter") {
+		t.Errorf("expected key=value error, got %v", err)
+	}
+
+	_, err = parseTUIFilter("approvals >=")
+	if err == nil || strings.Contains(err.Error(), "\n") || !strings.Contains(err.Error(), "column") {
+		t.Errorf("expected single line where error, got %q", err)
+	}
+}
+
+// Every filter flag of log has to be usable in the TUI as well
+func TestFilterKeys_MatchFlags(t *testing.T) {
+	renamed := map[string]string{
+		"review-status": "review",
+		"draft-status":  "draft",
+		"updated-since": "updated",
+		"created-since": "created",
+	}
+	notFilters := []string{"output", "all", "format", "columns", "sort", "group-by", "watch", "bell", "notify",
+		// The whole TUI filter can be a --where expression
+		"where",
+		// Chain filters are key=value pairs themselves
+		"chain-all", "chain-any"}
+
+	parser, err := kong.New(&CLI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range parser.Model.Children {
+		if cmd.Name != "log" {
+			continue
+		}
+		for _, f := range cmd.Flags {
+			if slices.Contains(notFilters, f.Name) {
+				continue
+			}
+			key := f.Name
+			if k, ok := renamed[key]; ok {
+				key = k
+			}
+			if _, ok := filterKeys[key]; !ok {
+				t.Errorf("--%s has no TUI filter key", f.Name)
+			}
+		}
+	}
+}
+
+func TestParseChainFilter(t *testing.T) {
+	got, err := parseChainFilter("review=approved checks=pass")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil || got.ReviewStatus != "approved" || got.Checks != "pass" {
+		t.Errorf("unexpected options %+v", got)
+	}
+
+	got, err = parseChainFilter(" ")
+	if err != nil || got != nil {
+		t.Errorf("expected no filter for empty input, got %+v (%v)", got, err)
+	}
+
+	for _, input := range []string{"author=bob", "checks=green", "review"} {
+		if _, err := parseChainFilter(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestFilterWholeChains(t *testing.T) {
+	// 0 -> 1 -> 2 -> 3
+	//   -> 4 -> 5
+	//   -> 6
+	d := makeTestData(
+		map[int]pr{
+			1: {number: 1, approvers: []string{"bob"}, checksState: "success"},
+			2: {number: 2, approvers: []string{"bob"}, checksState: "failure"},
+			3: {number: 3, checksState: "success"},
+			4: {number: 4, approvers: []string{"bob"}, checksState: "success"},
+			5: {number: 5, approvers: []string{"bob"}, checksState: "success"},
+			6: {number: 6, isDraft: true},
+		},
+		map[int]mapping{
+			0: {following: []int{1, 4, 6}},
+			1: {base: 0, following: []int{2}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+			4: {base: 0, following: []int{5}},
+			5: {base: 4, following: []int{}},
+			6: {base: 0, following: []int{}},
+		},
+	)
+
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want []int
+	}{
+		{"no chain filters", FilterOptions{}, []int{1, 4, 6}},
+		{"all approved", FilterOptions{ChainAll: &FilterOptions{ReviewStatus: "approved"}}, []int{4}},
+		{"any failing", FilterOptions{ChainAny: &FilterOptions{Checks: "fail"}}, []int{1}},
+		{"any draft", FilterOptions{ChainAny: &FilterOptions{DraftStatus: "draft"}}, []int{6}},
+		{"min depth", FilterOptions{MinDepth: 2}, []int{1, 4}},
+		{"max depth", FilterOptions{MaxDepth: 2}, []int{4, 6}},
+		{"depth range", FilterOptions{MinDepth: 2, MaxDepth: 2}, []int{4}},
+		{"combined", FilterOptions{ChainAll: &FilterOptions{DraftStatus: "ready"}, MaxDepth: 2}, []int{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterWholeChains(d, d.mappings, tt.opts)
+			if !reflect.DeepEqual(got[0].following, tt.want) {
+				t.Errorf("got %v, want %v", got[0].following, tt.want)
+			}
+		})
+	}
+
+	// Whole chains are kept, per PR filters apply after
+	m := filterWholeChains(d, d.mappings, FilterOptions{ChainAny: &FilterOptions{Checks: "fail"}})
+	nodes := visibleTree(d, m, 0, FilterOptions{Checks: "pass"})
+	if len(nodes) != 1 || nodes[0].number != 1 || len(nodes[0].children) != 1 || nodes[0].children[0].number != 3 {
+		t.Errorf("unexpected tree %+v", nodes)
+	}
+}
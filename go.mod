@@ -3,10 +3,12 @@ module github.com/meain/chainlink
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/alecthomas/kong v0.8.1
 	github.com/fatih/color v1.16.0
 	github.com/tcnksm/go-gitconfig v0.1.2
 	golang.org/x/net v0.17.0
+	golang.org/x/term v0.14.0
 )
 
 require (
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// graphEdge points from a PR to what it is based on, which is either
+// another (visible) PR or a branch.
+type graphEdge struct {
+	from   int
+	to     int
+	branch string
+}
+
+// chainGraph flattens the visible tree into the PRs to draw, the edges
+// between them and the branches the roots are based on.
+func chainGraph(d data, nodes []treeNode) ([]int, []graphEdge, []string) {
+	prs := []int{}
+	edges := []graphEdge{}
+	branches := []string{}
+	seenBranch := map[string]bool{}
+
+	var walk func(nodes []treeNode, parent int)
+	walk = func(nodes []treeNode, parent int) {
+		for _, n := range nodes {
+			prs = append(prs, n.number)
+
+			if parent != 0 {
+				edges = append(edges, graphEdge{from: n.number, to: parent})
+			} else {
+				base := d.prs[n.number].base
+				if !seenBranch[base] {
+					seenBranch[base] = true
+					branches = append(branches, base)
+				}
+				edges = append(edges, graphEdge{from: n.number, branch: base})
+			}
+
+			walk(n.children, n.number)
+		}
+	}
+	walk(nodes, 0)
+
+	return prs, edges, branches
+}
+
+// prColors returns the fill color based on the review state and the
+// border color based on the CI state of a PR.
+func prColors(p pr) (string, string) {
+	fill := "#ffffff"
+	switch {
+	case p.hasChangesRequested:
+		fill = "#ffd8d3"
+	case fullyApproved(p):
+		fill = "#d1f7d6"
+	}
+
+	stroke := "#8c959f"
+	switch p.checksState {
+	case "success":
+		stroke = "#1a7f37"
+	case "failure", "error":
+		stroke = "#cf222e"
+	case "pending", "expected":
+		stroke = "#9a6700"
+	}
+
+	return fill, stroke
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func formatDot(d data, mappings map[int]mapping, opts FilterOptions) string {
+	return formatDotGroups(d, mappings, []chainGroup{{opts: opts}})
+}
+
+// formatDotGroups draws every named group as a cluster of its own.
+// Nodes are prefixed with the group as a PR can be in several groups.
+func formatDotGroups(d data, mappings map[int]mapping, groups []chainGroup) string {
+	lines := []string{
+		"digraph chains {",
+		"  rankdir=RL;",
+		`  node [shape=box, style="rounded,filled", fontname="Helvetica"];`,
+	}
+
+	for i, g := range groups {
+		if len(g.name) == 0 {
+			lines = append(lines, dotNodes(d, mappings, g.opts, "", "  ")...)
+			continue
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("  subgraph cluster_%d {", i),
+			fmt.Sprintf("    label=%s;", dotQuote(g.name)))
+		lines = append(lines, dotNodes(d, mappings, g.opts, fmt.Sprintf("g%d_", i), "    ")...)
+		lines = append(lines, "  }")
+	}
+
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+func dotNodes(d data, mappings map[int]mapping, opts FilterOptions, prefix string, indent string) []string {
+	prs, edges, branches := chainGraph(d, visibleTree(d, mappings, 0, opts))
+
+	branchID := func(b string) string {
+		return dotQuote(prefix + b)
+	}
+
+	lines := []string{}
+	for _, b := range branches {
+		label := ""
+		if len(prefix) > 0 {
+			label = ", label=" + dotQuote(b)
+		}
+		lines = append(lines, fmt.Sprintf(
+			"%s%s [shape=ellipse, style=filled, fillcolor=%s%s];",
+			indent, branchID(b), dotQuote("#eaeef2"), label))
+	}
+
+	for _, n := range prs {
+		p := d.prs[n]
+		fill, stroke := prColors(p)
+		label := fmt.Sprintf("#%d %s\n%s", p.number, p.title, p.author)
+		lines = append(lines, fmt.Sprintf(
+			"%s%spr%d [label=%s, URL=%s, fillcolor=%s, color=%s, penwidth=2];",
+			indent,
+			prefix,
+			n,
+			strings.ReplaceAll(dotQuote(label), "\n", `\n`),
+			dotQuote(fmt.Sprintf("%s/pull/%d", d.url, n)),
+			dotQuote(fill),
+			dotQuote(stroke)))
+	}
+
+	for _, e := range edges {
+		if e.to != 0 {
+			lines = append(lines, fmt.Sprintf("%s%spr%d -> %spr%d;", indent, prefix, e.from, prefix, e.to))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s%spr%d -> %s;", indent, prefix, e.from, branchID(e.branch)))
+		}
+	}
+
+	return lines
+}
+
+var mermaidIDRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + s + `"`
+}
+
+func formatMermaid(d data, mappings map[int]mapping, opts FilterOptions) string {
+	return formatMermaidGroups(d, mappings, []chainGroup{{opts: opts}})
+}
+
+// formatMermaidGroups draws every named group as a subgraph, see
+// formatDotGroups.
+func formatMermaidGroups(d data, mappings map[int]mapping, groups []chainGroup) string {
+	lines := []string{"graph RL"}
+
+	for i, g := range groups {
+		if len(g.name) == 0 {
+			lines = append(lines, mermaidNodes(d, mappings, g.opts, "", "  ")...)
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("  subgraph group%d[%s]", i, mermaidQuote(g.name)))
+		lines = append(lines, mermaidNodes(d, mappings, g.opts, fmt.Sprintf("g%d_", i), "    ")...)
+		lines = append(lines, "  end")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func mermaidNodes(d data, mappings map[int]mapping, opts FilterOptions, prefix string, indent string) []string {
+	prs, edges, branches := chainGraph(d, visibleTree(d, mappings, 0, opts))
+
+	// Branch names can contain characters which aren't valid in ids,
+	// so number them to keep ids unique.
+	branchIDs := map[string]string{}
+	for i, b := range branches {
+		branchIDs[b] = fmt.Sprintf("%sbranch%d_%s", prefix, i, mermaidIDRe.ReplaceAllString(b, "_"))
+	}
+
+	lines := []string{}
+	for _, b := range branches {
+		lines = append(lines, fmt.Sprintf("%s%s([%s])", indent, branchIDs[b], mermaidQuote(b)))
+	}
+
+	for _, n := range prs {
+		p := d.prs[n]
+		label := fmt.Sprintf("#%d %s<br/>%s", p.number, p.title, p.author)
+		lines = append(lines, fmt.Sprintf("%s%spr%d[%s]", indent, prefix, n, mermaidQuote(label)))
+	}
+
+	for _, e := range edges {
+		if e.to != 0 {
+			lines = append(lines, fmt.Sprintf("%s%spr%d --> %spr%d", indent, prefix, e.from, prefix, e.to))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s%spr%d --> %s", indent, prefix, e.from, branchIDs[e.branch]))
+		}
+	}
+
+	for _, n := range prs {
+		fill, stroke := prColors(d.prs[n])
+		lines = append(lines,
+			fmt.Sprintf("%sclick %spr%d %s", indent, prefix, n, mermaidQuote(fmt.Sprintf("%s/pull/%d", d.url, n))),
+			fmt.Sprintf("%sstyle %spr%d fill:%s,stroke:%s,stroke-width:2px", indent, prefix, n, fill, stroke))
+	}
+
+	return lines
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func graphTestData() data {
+	// 0 -> 1 -> 2 (2 is filtered out by author) -> 3
+	return makeTestData(
+		map[int]pr{
+			1: {number: 1, base: "main", head: "one", title: "One", author: "alice", approvers: []string{"bob"}, checksState: "success"},
+			2: {number: 2, base: "one", head: "two", title: "Two", author: "bob", checksState: "failure"},
+			3: {number: 3, base: "two", head: "three", title: `Say "hi"`, author: "alice", hasChangesRequested: true},
+		},
+		map[int]mapping{
+			0: {following: []int{1}},
+			1: {base: 0, following: []int{2}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+		},
+	)
+}
+
+func TestFormatDot(t *testing.T) {
+	d := graphTestData()
+
+	got := formatDot(d, d.mappings, FilterOptions{})
+	for _, want := range []string{
+		`  "main" [shape=ellipse, style=filled, fillcolor="#eaeef2"];`,
+		`  pr1 [label="#1 One\nalice", URL="https://github.com/test/repo/pull/1", fillcolor="#d1f7d6", color="#1a7f37", penwidth=2];`,
+		`  pr3 [label="#3 Say \"hi\"\nalice", URL="https://github.com/test/repo/pull/3", fillcolor="#ffd8d3", color="#8c959f", penwidth=2];`,
+		"  pr1 -> \"main\";",
+		"  pr2 -> pr1;",
+		"  pr3 -> pr2;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatDot_Filtered(t *testing.T) {
+	d := graphTestData()
+
+	got := formatDot(d, d.mappings, FilterOptions{Author: "alice"})
+	if strings.Contains(got, "pr2") {
+		t.Errorf("did not expect filtered PR in output:\n%s", got)
+	}
+	// #3 is attached to its closest visible ancestor
+	if !strings.Contains(got, "  pr3 -> pr1;") {
+		t.Errorf("expected #3 to point to #1, got:\n%s", got)
+	}
+}
+
+func TestFormatMermaid(t *testing.T) {
+	d := graphTestData()
+
+	got := formatMermaid(d, d.mappings, FilterOptions{Author: "alice", Checks: "all"})
+	want := strings.Join([]string{
+		"graph RL",
+		`  branch0_main(["main"])`,
+		`  pr1["#1 One<br/>alice"]`,
+		`  pr3["#3 Say #quot;hi#quot;<br/>alice"]`,
+		"  pr1 --> branch0_main",
+		"  pr3 --> pr1",
+		`  click pr1 "https://github.com/test/repo/pull/1"`,
+		"  style pr1 fill:#d1f7d6,stroke:#1a7f37,stroke-width:2px",
+		`  click pr3 "https://github.com/test/repo/pull/3"`,
+		"  style pr3 fill:#ffd8d3,stroke:#8c959f,stroke-width:2px",
+	}, "\n")
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatDotGroups(t *testing.T) {
+	d := graphTestData()
+
+	got := formatDotGroups(d, d.mappings, groupChains(d, d.mappings, "author", FilterOptions{}))
+	for _, want := range []string{
+		"  subgraph cluster_0 {",
+		`    label="alice";`,
+		`    "g0_main" [shape=ellipse, style=filled, fillcolor="#eaeef2", label="main"];`,
+		"    g0_pr3 -> g0_pr1;",
+		`    label="bob";`,
+		"    g1_pr2 -> \"g1_one\";",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
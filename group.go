@@ -0,0 +1,190 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+)
+
+// comparePRs orders PRs for --sort, falling back to the PR number so
+// that the order is stable between runs.
+func comparePRs(a, b pr, by string) int {
+	c := 0
+	switch by {
+	case "created":
+		c = b.createdAt.Compare(a.createdAt) // newest first
+	case "updated":
+		c = b.updatedAt.Compare(a.updatedAt) // most recently updated first
+	case "size":
+		c = cmp.Compare(a.additions+a.deletions, b.additions+b.deletions)
+	case "age":
+		c = a.createdAt.Compare(b.createdAt) // oldest first
+	case "title":
+		c = cmp.Compare(strings.ToLower(a.title), strings.ToLower(b.title))
+	}
+
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(a.number, b.number)
+}
+
+// sortMappings returns a copy of the mappings with the PRs at every
+// level of the tree sorted. "none" keeps the order from the API. PRs
+// promoted in place of a filtered out parent take the parent's spot.
+func sortMappings(d data, m map[int]mapping, by string) map[int]mapping {
+	if len(by) == 0 || by == "none" {
+		return m
+	}
+
+	nm := make(map[int]mapping, len(m))
+	for k, v := range m {
+		following := slices.Clone(v.following)
+		slices.SortFunc(following, func(a, b int) int {
+			return comparePRs(d.prs[a], d.prs[b], by)
+		})
+		nm[k] = mapping{base: v.base, following: following}
+	}
+
+	return nm
+}
+
+// prGroups returns the groups a PR belongs to for --group-by. A PR can
+// be part of more than one group when grouping by label or reviewer.
+func prGroups(p pr, by string) []string {
+	switch by {
+	case "author":
+		return []string{p.author}
+	case "label":
+		if len(p.labels) == 0 {
+			return []string{"no label"}
+		}
+		return p.labels
+	case "reviewer":
+		if len(p.reviewers) == 0 {
+			return []string{"no reviewer"}
+		}
+		return p.reviewers
+	case "review-status":
+		return []string{reviewState(p)}
+	default:
+		return nil
+	}
+}
+
+// chainGroup is a section of the output along with the filters which
+// select the PRs in it, the ones of the command narrowed down to the
+// group
+type chainGroup struct {
+	name string
+	opts FilterOptions
+}
+
+// inGroup narrows down the filters to the PRs of a --group-by section
+func inGroup(opts FilterOptions, by, name string) FilterOptions {
+	var group filterExpr = groupExpr{by: by, name: name}
+	if opts.Where != nil {
+		group = andExpr{opts.Where, group}
+	}
+
+	opts.Where = group
+	return opts.compile()
+}
+
+// groupChains splits the visible PRs into sections, ordered by name
+// with PRs missing the attribute last. With "none" everything is in a
+// single unnamed group.
+func groupChains(d data, mappings map[int]mapping, by string, opts FilterOptions) []chainGroup {
+	if len(by) == 0 || by == "none" {
+		return []chainGroup{{opts: opts}}
+	}
+
+	names := []string{}
+	var walk func(nodes []treeNode)
+	walk = func(nodes []treeNode) {
+		for _, n := range nodes {
+			for _, g := range prGroups(d.prs[n.number], by) {
+				if !slices.Contains(names, g) {
+					names = append(names, g)
+				}
+			}
+			walk(n.children)
+		}
+	}
+	walk(visibleTree(d, mappings, 0, opts))
+
+	slices.SortFunc(names, func(a, b string) int {
+		aNone, bNone := strings.HasPrefix(a, "no "), strings.HasPrefix(b, "no ")
+		if aNone != bNone {
+			if aNone {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a, b)
+	})
+
+	groups := []chainGroup{}
+	for _, name := range names {
+		groups = append(groups, chainGroup{name: name, opts: inGroup(opts, by, name)})
+	}
+
+	return groups
+}
+
+// isGrouped tells apart named groups from the single group used when
+// not grouping. No groups at all means nothing matched a grouping.
+func isGrouped(groups []chainGroup) bool {
+	return len(groups) != 1 || len(groups[0].name) > 0
+}
+
+// printGroups prints the tree of each group with a heading in front
+// of it when grouping.
+func printGroups(
+	d data,
+	mappings map[int]mapping,
+	output string,
+	tmpl *template.Template,
+	groups []chainGroup,
+	changes map[int][]prChange,
+) error {
+	for i, g := range groups {
+		if len(g.name) > 0 {
+			if i > 0 {
+				fmt.Println()
+			}
+			if output == "markdown" {
+				fmt.Printf("## %s\n\n", g.name)
+			} else {
+				fmt.Println(color.New(color.Bold).Sprint(g.name))
+			}
+		}
+
+		err := printChildren(d, mappings, 0, output, tmpl, g.opts, changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func buildJSONGroups(d data, mappings map[int]mapping, groups []chainGroup) JSONGroupedOutput {
+	output := JSONGroupedOutput{Groups: []JSONGroup{}}
+	for _, g := range groups {
+		output.Groups = append(output.Groups, JSONGroup{
+			Name:   g.name,
+			Chains: collectJSONChains(d, mappings, 0, g.opts),
+		})
+	}
+
+	for _, p := range d.problems {
+		output.Problems = append(output.Problems, JSONProblem{Kind: p.kind, PRs: p.prs, Message: p.message})
+	}
+
+	return output
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func groupTestData() data {
+	day := func(n int) time.Time {
+		return time.Date(2026, 1, n, 0, 0, 0, 0, time.UTC)
+	}
+
+	// 0 -> 1 -> 3
+	//        -> 4
+	//   -> 2
+	return makeTestData(
+		map[int]pr{
+			1: {number: 1, title: "beta", author: "alice", createdAt: day(2), updatedAt: day(9), additions: 300, labels: []string{"backend"}},
+			2: {number: 2, title: "Alpha", author: "bob", createdAt: day(1), updatedAt: day(5), additions: 10, approvers: []string{"alice"}},
+			3: {number: 3, title: "gamma", author: "bob", createdAt: day(4), updatedAt: day(6), additions: 50, labels: []string{"backend", "ready"}},
+			4: {number: 4, title: "delta", author: "alice", createdAt: day(3), updatedAt: day(7), additions: 20, hasChangesRequested: true},
+		},
+		map[int]mapping{
+			0: {following: []int{1, 2}},
+			1: {base: 0, following: []int{3, 4}},
+			2: {base: 0, following: []int{}},
+			3: {base: 1, following: []int{}},
+			4: {base: 1, following: []int{}},
+		},
+	)
+}
+
+func TestSortMappings(t *testing.T) {
+	d := groupTestData()
+
+	tests := []struct {
+		by       string
+		roots    []int
+		children []int
+	}{
+		{"none", []int{1, 2}, []int{3, 4}},
+		{"number", []int{1, 2}, []int{3, 4}},
+		{"created", []int{1, 2}, []int{3, 4}},
+		{"updated", []int{1, 2}, []int{4, 3}},
+		{"size", []int{2, 1}, []int{4, 3}},
+		{"age", []int{2, 1}, []int{4, 3}},
+		{"title", []int{2, 1}, []int{4, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			sorted := sortMappings(d, d.mappings, tt.by)
+			if !slices.Equal(sorted[0].following, tt.roots) {
+				t.Errorf("roots: got %v, want %v", sorted[0].following, tt.roots)
+			}
+			if !slices.Equal(sorted[1].following, tt.children) {
+				t.Errorf("children: got %v, want %v", sorted[1].following, tt.children)
+			}
+		})
+	}
+
+	// The original mappings are left untouched
+	sortMappings(d, d.mappings, "title")
+	if !slices.Equal(d.mappings[0].following, []int{1, 2}) {
+		t.Errorf("expected mappings to not be modified, got %v", d.mappings[0].following)
+	}
+}
+
+func TestGroupChains(t *testing.T) {
+	d := groupTestData()
+
+	tests := []struct {
+		by   string
+		opts FilterOptions
+		want map[string][]int // group name to the numbers of the roots
+	}{
+		{"author", FilterOptions{}, map[string][]int{"alice": {1}, "bob": {3, 2}}},
+		{"label", FilterOptions{}, map[string][]int{"backend": {1}, "ready": {3}, "no label": {4, 2}}},
+		{"review-status", FilterOptions{}, map[string][]int{"approved": {2}, "changes-requested": {4}, "pending": {1}}},
+		{"author", FilterOptions{Author: "bob"}, map[string][]int{"bob": {3, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			groups := groupChains(d, d.mappings, tt.by, tt.opts)
+
+			if len(groups) != len(tt.want) {
+				t.Fatalf("expected %d groups, got %d", len(tt.want), len(groups))
+			}
+			for _, g := range groups {
+				roots := []int{}
+				for _, n := range visibleTree(d, d.mappings, 0, g.opts) {
+					roots = append(roots, n.number)
+				}
+				if !slices.Equal(roots, tt.want[g.name]) {
+					t.Errorf("group %q: got %v, want %v", g.name, roots, tt.want[g.name])
+				}
+			}
+		})
+	}
+
+	// PRs without the attribute are grouped last
+	groups := groupChains(d, d.mappings, "label", FilterOptions{})
+	if groups[len(groups)-1].name != "no label" {
+		t.Errorf("expected the no label group last, got %q", groups[len(groups)-1].name)
+	}
+
+	if isGrouped(groupChains(d, d.mappings, "none", FilterOptions{})) {
+		t.Error("expected a single unnamed group without grouping")
+	}
+}
+
+func TestInGroup(t *testing.T) {
+	where, err := parseWhere("approvals >= 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := inGroup(FilterOptions{Author: "alice", Where: where}, "label", "backend")
+	if opts.Author != "alice" {
+		t.Errorf("expected the other filters to be kept, got %+v", opts)
+	}
+	if got := opts.Where.String(); got != "approvals >= 1 and group(label) == backend" {
+		t.Errorf("unexpected expression %q", got)
+	}
+
+	if got := inGroup(FilterOptions{}, "author", "bob").Where.String(); got != "group(author) == bob" {
+		t.Errorf("unexpected expression %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+//go:embed report.html
+var reportTemplate string
+
+var reportFuncs = template.FuncMap{
+	"authorColor": func(author string) template.CSS {
+		rgb := stringRGB(author)
+		return template.CSS(fmt.Sprintf("#%02x%02x%02x", int(rgb.R*255), int(rgb.G*255), int(rgb.B*255)))
+	},
+	"join":        strings.Join,
+	"blockReason": func(reason string) string { return blockReasons[reason] },
+	// fullyApproved is whether the PR has all the approvals it needs,
+	// for telling partial approvals apart
+	"fullyApproved": func(p JSONPullRequest) bool {
+		return fullyApproved(pr{
+			approvers:           p.Approvers,
+			requiredApprovals:   p.RequiredApprovals,
+			hasChangesRequested: p.HasChangesRequested,
+		})
+	},
+	"age": func(t time.Time) string {
+		return formatAge(time.Since(t))
+	},
+	// ciClass maps the CI state to one of success, failure or pending
+	"ciClass": func(state string) string {
+		switch state {
+		case "success":
+			return "success"
+		case "failure", "error":
+			return "failure"
+		case "pending", "expected":
+			return "pending"
+		default:
+			return ""
+		}
+	},
+}
+
+var report = template.Must(template.New("report").Funcs(reportFuncs).Parse(reportTemplate))
+
+type htmlReport struct {
+	Repo        string
+	URL         string
+	GeneratedAt time.Time
+	Chains      []JSONChain
+	Groups      []JSONGroup
+	Problems    []JSONProblem
+}
+
+// formatHTML renders a standalone HTML page of the chains of repo, as
+// org/repo. It uses the same data as the JSON output.
+func formatHTML(d data, repo string, mappings map[int]mapping, opts FilterOptions) (string, error) {
+	return formatHTMLGroups(d, repo, mappings, []chainGroup{{opts: opts}})
+}
+
+// formatHTMLGroups renders a section for every named group
+func formatHTMLGroups(d data, repo string, mappings map[int]mapping, groups []chainGroup) (string, error) {
+	page := htmlReport{
+		Repo:        repo,
+		URL:         d.url,
+		GeneratedAt: time.Now(),
+	}
+
+	if isGrouped(groups) {
+		jsonOutput := buildJSONGroups(d, mappings, groups)
+		page.Groups, page.Problems = jsonOutput.Groups, jsonOutput.Problems
+		for _, g := range page.Groups {
+			page.Chains = append(page.Chains, g.Chains...)
+		}
+	} else {
+		jsonOutput := buildJSONOutput(d, mappings, 0, groups[0].opts)
+		page.Chains, page.Problems = jsonOutput.Chains, jsonOutput.Problems
+	}
+
+	var sb strings.Builder
+	err := report.Execute(&sb, page)
+	if err != nil {
+		return "", fmt.Errorf("unable to render html: %v", err)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatHTML(t *testing.T) {
+	d := makeTestData(
+		map[int]pr{
+			1: {number: 1, title: "Base <work>", author: "alice", head: "one", approvers: []string{"bob"}, checksState: "success", createdAt: time.Now()},
+			2: {number: 2, title: "Follow up", author: "bob", head: "two", hasChangesRequested: true, mergeable: "conflicting", labels: []string{"wip"}, createdAt: time.Now()},
+			3: {number: 3, title: "Half way", author: "carol", head: "three", approvers: []string{"alice"}, requiredApprovals: 2, createdAt: time.Now()},
+		},
+		map[int]mapping{
+			0: {following: []int{1}},
+			1: {base: 0, following: []int{2}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+		},
+	)
+	d.problems = []problem{{kind: "cycle", prs: []int{3, 4}, message: "PRs #3, #4 form a cycle"}}
+
+	page, err := formatHTML(d, "test/repo", d.mappings, FilterOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rgb := stringRGB("alice")
+	for _, want := range []string{
+		"<title>PR chains for test/repo</title>",
+		"<details open>",
+		`<a class="number" href="https://github.com/test/repo/pull/1">#1</a>`,
+		"Base &lt;work&gt;",
+		`<span class="badge approved">approved by bob</span>`,
+		`<span class="badge partially-approved">approved by alice (1/2)</span>`,
+		`<span class="badge ci-success">CI success</span>`,
+		`<span class="badge changes-requested">changes requested</span>`,
+		`<span class="badge conflicting">conflicting</span>`,
+		`<span class="badge label">wip</span>`,
+		"PRs #3, #4 form a cycle",
+		fmt.Sprintf(`style="color: #%02x%02x%02x"`, int(rgb.R*255), int(rgb.G*255), int(rgb.B*255)),
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+
+	// Standalone: nothing is loaded from elsewhere
+	for _, external := range []string{"<link", "<script", "@import"} {
+		if strings.Contains(page, external) {
+			t.Errorf("did not expect %q in standalone page", external)
+		}
+	}
+	// The heading names the repo on GitHub Enterprise as well
+	d.url = "https://github.example.com/test/repo"
+	page, err = formatHTML(d, "test/repo", d.mappings, FilterOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(page, "<title>PR chains for test/repo</title>") {
+		t.Errorf("expected the org/repo in the title, got:\n%s", page)
+	}
+}
@@ -3,7 +3,8 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
+	"os"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -28,7 +29,7 @@ func filterChains(m map[int]mapping) map[int]mapping {
 	return nm
 }
 
-func logChains(d data, all bool, opts FilterOptions) {
+func logChains(d data, repo string, all bool, tmpl *template.Template, opts FilterOptions) error {
 	mappings := d.mappings
 	if !all {
 		mappings = filterChains(d.mappings)
@@ -42,50 +43,239 @@ func logChains(d data, all bool, opts FilterOptions) {
 		} else {
 			fmt.Println("No PR chains")
 		}
-		return
+		return nil
 	}
 
-	if CLI.Log.Output == "json" {
-		jsonOutput := buildJSONOutput(d, mappings, 0, opts)
-		output, _ := json.MarshalIndent(jsonOutput, "", "  ")
+	if tmpl != nil {
+		switch CLI.Log.Output {
+		case "default", "small", "markdown":
+		default:
+			return fmt.Errorf("--format can't be used with --output %s", CLI.Log.Output)
+		}
+	}
+
+	mappings = filterWholeChains(d, mappings, opts)
+	mappings = sortMappings(d, mappings, CLI.Log.Sort)
+	groups := groupChains(d, mappings, CLI.Log.GroupBy, opts)
+
+	switch CLI.Log.Output {
+	case "json":
+		var output []byte
+		if isGrouped(groups) {
+			output, _ = json.MarshalIndent(buildJSONGroups(d, mappings, groups), "", "  ")
+		} else {
+			output, _ = json.MarshalIndent(buildJSONOutput(d, mappings, 0, opts), "", "  ")
+		}
 		fmt.Println(string(output))
-	} else {
-		printChildren(d, mappings, 0, 0, all, CLI.Log.Output, opts)
+	case "dot":
+		printProblems(d.problems)
+		fmt.Println(formatDotGroups(d, mappings, groups))
+	case "mermaid":
+		printProblems(d.problems)
+		fmt.Println(formatMermaidGroups(d, mappings, groups))
+	case "csv", "tsv", "ndjson":
+		printProblems(d.problems)
+		out, err := formatFlatGroups(d, mappings, CLI.Log.Output, CLI.Log.Columns, groups)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	case "html":
+		page, err := formatHTMLGroups(d, repo, mappings, groups)
+		if err != nil {
+			return err
+		}
+		fmt.Print(page)
+	default:
+		printProblems(d.problems)
+		return printGroups(d, mappings, CLI.Log.Output, tmpl, groups, nil)
+	}
+
+	return nil
+}
+
+// printProblems prints warnings to stderr so that they don't end up
+// in output which is piped elsewhere.
+func printProblems(problems []problem) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow("warning:"), p.message)
 	}
 }
 
+// treeNode is a PR which passed the filters along with its visible
+// descendants.
+type treeNode struct {
+	number   int
+	children []treeNode
+}
+
+// visibleTree builds the tree of PRs to display. PRs which don't match
+// the filters are skipped and their descendants promoted in their
+// place. This is necessary as otherwise if a parent PR is filtered
+// out, its children won't be printed. For example, if I want to get
+// the list of unapproved PRs, I want to see all unapproved PRs in the
+// chain, even if their parent PRs are approved.
+func visibleTree(d data, mappings map[int]mapping, base int, opts FilterOptions) []treeNode {
+	nodes := []treeNode{}
+	for _, p := range mappings[base].following {
+		if ApplyPRFilters(d.prs[p], opts) {
+			nodes = append(nodes, treeNode{
+				number:   p,
+				children: visibleTree(d, mappings, p, opts),
+			})
+		} else {
+			nodes = append(nodes, visibleTree(d, mappings, p, opts)...)
+		}
+	}
+	return nodes
+}
+
+// treeStyle is the set of connectors used to draw the tree
+type treeStyle struct {
+	branch string // for a node with more siblings after it
+	last   string // for the last node among its siblings
+	pipe   string // continues the line of a parent with more siblings
+	space  string // below the last node among its siblings
+}
+
+var (
+	unicodeTree = treeStyle{"├─ ", "└─ ", "│  ", "   "}
+	asciiTree   = treeStyle{"|- ", "`- ", "|  ", "   "}
+	indentTree  = treeStyle{"  ", "  ", "  ", "  "}
+)
+
+// treeStyleForTerm falls back to plain ASCII on terminals which can't
+// be trusted to render box drawing characters.
+func treeStyleForTerm(term string) treeStyle {
+	if term == "dumb" {
+		return asciiTree
+	}
+	return unicodeTree
+}
+
+// prPosition is where a PR shows up within the displayed chains
+type prPosition struct {
+	depth  int
+	root   int
+	index  int // 1-based, in display order
+	length int
+}
+
+func chainPositions(nodes []treeNode) map[int]prPosition {
+	positions := map[int]prPosition{}
+
+	var walk func(nodes []treeNode, depth int, root int, members *[]int)
+	walk = func(nodes []treeNode, depth int, root int, members *[]int) {
+		for _, n := range nodes {
+			*members = append(*members, n.number)
+			positions[n.number] = prPosition{depth: depth, root: root, index: len(*members)}
+			walk(n.children, depth+1, root, members)
+		}
+	}
+
+	for _, n := range nodes {
+		members := []int{}
+		walk([]treeNode{n}, 0, n.number, &members)
+		for _, m := range members {
+			pos := positions[m]
+			pos.length = len(members)
+			positions[m] = pos
+		}
+	}
+
+	return positions
+}
+
 func printChildren(
 	d data,
 	mappings map[int]mapping,
-	base, level int,
-	all bool,
+	base int,
 	output string,
+	tmpl *template.Template,
 	opts FilterOptions,
-) {
-	for _, p := range mappings[base].following {
-		newLevel := level
-		indent := strings.Repeat("  ", level) // TODO: print a tree like structure
-		var line string
-		switch output {
-		case "small":
-			line = formatPRSmall(d.prs[p], d.url)
-		case "markdown":
-			line = formatPRMarkdown(d.prs[p], d.url)
+	changes map[int][]prChange,
+) error {
+	style := indentTree
+	if output == "default" {
+		style = treeStyleForTerm(os.Getenv("TERM"))
+	}
+
+	nodes := visibleTree(d, mappings, base, opts)
+
+	format := func(n int) (string, error) {
+		return formatLine(d.prs[n], d.url, output), nil
+	}
+	if tmpl != nil {
+		positions := chainPositions(nodes)
+		format = func(n int) (string, error) {
+			pos := positions[n]
+			return executeFormat(tmpl, templatePR{
+				JSONPullRequest: toJSONPullRequest(d.prs[n], d.url),
+				Depth:           pos.depth,
+				Root:            pos.root,
+				Position:        pos.index,
+				ChainLength:     pos.length,
+			})
+		}
+	}
+
+	lines, err := renderTree(nodes, "", true, style, func(n int) (string, error) {
+		line, err := format(n)
+		return annotateChanges(line, changes[n]), err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+func renderTree(
+	nodes []treeNode,
+	prefix string,
+	root bool,
+	style treeStyle,
+	format func(n int) (string, error),
+) ([]string, error) {
+	lines := []string{}
+	for i, n := range nodes {
+		connector, childPrefix := "", ""
+		switch {
+		case root:
+		case i == len(nodes)-1:
+			connector, childPrefix = style.last, prefix+style.space
 		default:
-			line = formatPR(d.prs[p], d.url)
+			connector, childPrefix = style.branch, prefix+style.pipe
 		}
 
-		// This is necessary as otherwise if a parent PR is filtered
-		// out, its children won't be printed. For example, if I want
-		// to get the list of unapproved PRs, I want to see all
-		// unapproved PRs in the chain, even if their parent PRs are
-		// approved.
-		if ApplyPRFilters(d.prs[p], opts) {
-			fmt.Println(indent + line)
-			newLevel++
+		line, err := format(n.number)
+		if err != nil {
+			return nil, err
 		}
+		lines = append(lines, prefix+connector+line)
 
-		printChildren(d, mappings, p, newLevel, all, output, opts)
+		children, err := renderTree(n.children, childPrefix, false, style, format)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, children...)
+	}
+	return lines, nil
+}
+
+func formatLine(p pr, url string, output string) string {
+	switch output {
+	case "small":
+		return formatPRSmall(p, url)
+	case "markdown":
+		return formatPRMarkdown(p, url)
+	default:
+		return formatPR(p, url)
 	}
 }
 
@@ -93,6 +283,12 @@ func printChildren(
 // using hsl and converting to rgb as it is easier to make it look
 // nicer for random colors
 func generateColor(str string) *color.Color {
+	rgb := stringRGB(str)
+	return color.New(38, 2, color.Attribute(rgb.R*255), color.Attribute(rgb.G*255), color.Attribute(rgb.B*255))
+}
+
+// stringRGB is the color used by generateColor
+func stringRGB(str string) RGB {
 	authorSum := 0
 	for _, c := range str {
 		authorSum += int(c)
@@ -100,9 +296,7 @@ func generateColor(str string) *color.Color {
 
 	authorHash := authorSum % 355
 	hsl := HSL{float64(authorHash), 0.5, .5}
-	rgb := HSLToRGB(hsl)
-
-	return color.New(38, 2, color.Attribute(rgb.R*255), color.Attribute(rgb.G*255), color.Attribute(rgb.B*255))
+	return HSLToRGB(hsl)
 }
 
 func ciIndicator(state string) string {
@@ -122,11 +316,29 @@ func ciIndicator(state string) string {
 	}
 }
 
+// approvalIndicator shows the number of approvals out of the required
+// ones, green once there are enough
+func approvalIndicator(p pr) string {
+	if len(p.approvers) == 0 {
+		return ""
+	}
+
+	text := fmt.Sprintf("✔%d", len(p.approvers))
+	if p.requiredApprovals > 0 {
+		text += fmt.Sprintf("/%d", p.requiredApprovals)
+	}
+
+	if fullyApproved(p) {
+		return color.New(color.FgGreen).Sprint(text)
+	}
+	return color.New(color.FgYellow).Sprint(text)
+}
+
 func formatPRSmall(p pr, url string) string {
 	green := color.New(color.FgGreen).SprintFunc()
 	number := fmt.Sprintf("#%d", p.number)
 
-	if len(p.approvedBy) > 0 {
+	if fullyApproved(p) {
 		number = green(number)
 	}
 
@@ -158,19 +370,13 @@ func formatPR(p pr, url string) string {
 	green := color.New(color.FgGreen).SprintFunc()
 	number := fmt.Sprintf("#%d", p.number)
 
-	if len(p.approvedBy) > 0 {
+	if fullyApproved(p) {
 		number = green(number)
 	}
 
-	age := time.Since(p.createdAt)
-	ageStr := ""
-	switch {
-	case age < 24*time.Hour:
-		ageStr = fmt.Sprintf("%.0fh", age.Hours())
-	case age < 30*24*time.Hour:
-		ageStr = fmt.Sprintf("%.0fd", age.Hours()/24)
-	default:
-		ageStr = fmt.Sprintf("%.0fmo", age.Hours()/24/30)
+	approvals := approvalIndicator(p)
+	if approvals != "" {
+		approvals = " " + approvals
 	}
 
 	ci := ciIndicator(p.checksState)
@@ -178,23 +384,50 @@ func formatPR(p pr, url string) string {
 		ci = " " + ci
 	}
 
+	blocked := ""
+	if reason := blockedBy(p); len(reason) > 0 {
+		blocked = " " + color.New(color.FgRed).Sprint("blocked by "+blockReasons[reason])
+	}
+
 	line := fmt.Sprintf(
-		"\x1b]8;;%s/pull/%d\x07%s\x1b]8;;\x07 %s (%s) [%s] %s ago%s",
-		url,
-		p.number,
-		number,
+		"%s %s (%s) [%s] %s ago%s%s%s",
+		hyperlink(fmt.Sprintf("%s/pull/%d", url, p.number), number),
 		p.title,
 		author,
 		p.head,
-		ageStr,
-		ci)
+		formatAge(time.Since(p.createdAt)),
+		approvals,
+		ci,
+		blocked)
 
 	return line
 }
 
+// hyperlink makes text clickable in terminals supporting OSC 8
+func hyperlink(url, text string) string {
+	return fmt.Sprintf("\x1b]8;;%s\x07%s\x1b]8;;\x07", url, text)
+}
+
+func formatAge(age time.Duration) string {
+	switch {
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%.0fh", age.Hours())
+	case age < 30*24*time.Hour:
+		return fmt.Sprintf("%.0fd", age.Hours()/24)
+	default:
+		return fmt.Sprintf("%.0fmo", age.Hours()/24/30)
+	}
+}
+
 func buildJSONOutput(d data, mappings map[int]mapping, base int, opts FilterOptions) JSONOutput {
 	chains := collectJSONChains(d, mappings, base, opts)
-	return JSONOutput{Chains: chains}
+
+	problems := []JSONProblem{}
+	for _, p := range d.problems {
+		problems = append(problems, JSONProblem{Kind: p.kind, PRs: p.prs, Message: p.message})
+	}
+
+	return JSONOutput{Chains: chains, Problems: problems}
 }
 
 // collectJSONChains traverses children even when a parent doesn't match the
@@ -214,14 +447,41 @@ func collectJSONChains(d data, mappings map[int]mapping, base int, opts FilterOp
 }
 
 func buildJSONChain(d data, mappings map[int]mapping, prNumber int, opts FilterOptions) JSONChain {
-	p := d.prs[prNumber]
-	jsonPR := JSONPullRequest{
+	jsonPR := toJSONPullRequest(d.prs[prNumber], d.url)
+	children := collectJSONChains(d, mappings, prNumber, opts)
+
+	chain := JSONChain{
+		PullRequest: jsonPR,
+		Status:      "ready",
+		BlockedBy:   chainBlockedBy(d.prs[prNumber], children),
+		Children:    children,
+	}
+	if chain.BlockedBy != nil {
+		chain.Status = "blocked"
+	}
+
+	return chain
+}
+
+// firstApprover is kept in the JSON output as approvedBy for scripts
+// written before all approvers were tracked
+func firstApprover(p pr) string {
+	if len(p.approvers) == 0 {
+		return ""
+	}
+	return p.approvers[0]
+}
+
+func toJSONPullRequest(p pr, url string) JSONPullRequest {
+	return JSONPullRequest{
 		Number:              p.number,
 		Base:                p.base,
 		Head:                p.head,
 		Title:               p.title,
 		Author:              p.author,
-		ApprovedBy:          p.approvedBy,
+		ApprovedBy:          firstApprover(p),
+		Approvers:           p.approvers,
+		RequiredApprovals:   p.requiredApprovals,
 		HasChangesRequested: p.hasChangesRequested,
 		HasComments:         p.hasComments,
 		Labels:              p.labels,
@@ -229,17 +489,14 @@ func buildJSONChain(d data, mappings map[int]mapping, prNumber int, opts FilterO
 		CreatedAt:           p.createdAt,
 		UpdatedAt:           p.updatedAt,
 		Mergeable:           p.mergeable,
+		MergeState:          p.mergeState,
+		ReviewDecision:      p.reviewDecision,
+		Status:              prStatus(p),
+		BlockedBy:           blockedBy(p),
 		ChecksState:         p.checksState,
 		Reviewers:           p.reviewers,
 		Additions:           p.additions,
 		Deletions:           p.deletions,
-		URL:                 fmt.Sprintf("%s/pull/%d", d.url, p.number),
-	}
-
-	children := collectJSONChains(d, mappings, prNumber, opts)
-
-	return JSONChain{
-		PullRequest: jsonPR,
-		Children:    children,
+		URL:                 fmt.Sprintf("%s/pull/%d", url, p.number),
 	}
 }
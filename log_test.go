@@ -1,8 +1,12 @@
 package main
 
 import (
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/fatih/color"
 )
 
 // helper to build test data with PRs and mappings
@@ -259,3 +263,135 @@ func TestCollectJSONChains_CreatedSinceFilter(t *testing.T) {
 		t.Errorf("expected PR #2, got #%d", output.Chains[0].PullRequest.Number)
 	}
 }
+
+func TestRenderTree(t *testing.T) {
+	color.NoColor = true
+
+	// 0 -> 1 -> 2 -> 4
+	//        -> 3
+	// 0 -> 5
+	d := makeTestData(
+		map[int]pr{
+			1: {number: 1, title: "one", author: "alice"},
+			2: {number: 2, title: "two", author: "bob"},
+			3: {number: 3, title: "three", author: "alice"},
+			4: {number: 4, title: "four", author: "alice"},
+			5: {number: 5, title: "five", author: "alice"},
+		},
+		map[int]mapping{
+			0: {following: []int{1, 5}},
+			1: {base: 0, following: []int{2, 3}},
+			2: {base: 1, following: []int{4}},
+			3: {base: 1, following: []int{}},
+			4: {base: 2, following: []int{}},
+			5: {base: 0, following: []int{}},
+		},
+	)
+
+	tests := []struct {
+		name  string
+		style treeStyle
+		opts  FilterOptions
+		want  []string
+	}{
+		{
+			name:  "unicode",
+			style: unicodeTree,
+			want: []string{
+				"#1 one",
+				"├─ #2 two",
+				"│  └─ #4 four",
+				"└─ #3 three",
+				"#5 five",
+			},
+		},
+		{
+			name:  "ascii",
+			style: asciiTree,
+			want: []string{
+				"#1 one",
+				"|- #2 two",
+				"|  `- #4 four",
+				"`- #3 three",
+				"#5 five",
+			},
+		},
+		{
+			name:  "indent",
+			style: indentTree,
+			want: []string{
+				"#1 one",
+				"  #2 two",
+				"    #4 four",
+				"  #3 three",
+				"#5 five",
+			},
+		},
+		{
+			// #2 is filtered out, so #4 takes its place and #3
+			// becomes the last child of #1
+			name:  "filtered intermediate",
+			style: unicodeTree,
+			opts:  FilterOptions{Author: "alice"},
+			want: []string{
+				"#1 one",
+				"├─ #4 four",
+				"└─ #3 three",
+				"#5 five",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := visibleTree(d, d.mappings, 0, tt.opts)
+			got, err := renderTree(nodes, "", true, tt.style, func(n int) (string, error) {
+				return formatPRSmall(d.prs[n], d.url), nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestTreeStyleForTerm(t *testing.T) {
+	if treeStyleForTerm("dumb") != asciiTree {
+		t.Error("expected ascii tree for dumb terminal")
+	}
+	if treeStyleForTerm("xterm-256color") != unicodeTree {
+		t.Error("expected unicode tree for xterm")
+	}
+}
+
+func TestApprovalIndicator(t *testing.T) {
+	defer func(v bool) { color.NoColor = v }(color.NoColor)
+	color.NoColor = true
+
+	tests := []struct {
+		name string
+		pr   pr
+		want string
+	}{
+		{"no approvals", pr{requiredApprovals: 2}, ""},
+		{"no required count", pr{approvers: []string{"bob"}}, "✔1"},
+		{"partially approved", pr{approvers: []string{"bob"}, requiredApprovals: 2}, "✔1/2"},
+		{"fully approved", pr{approvers: []string{"bob", "carol"}, requiredApprovals: 2}, "✔2/2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approvalIndicator(tt.pr); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	p := pr{number: 1, title: "One", author: "alice", head: "one", approvers: []string{"bob", "carol"}, requiredApprovals: 2, checksState: "success", createdAt: time.Now()}
+	if got := formatPR(p, "https://github.com/test/repo"); !strings.Contains(got, "ago ✔2/2 ✓") {
+		t.Errorf("expected approvals before the CI state, got %q", got)
+	}
+}
@@ -1,62 +1,115 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/fatih/color"
 	"github.com/tcnksm/go-gitconfig"
 	"golang.org/x/net/context"
 )
 
+// FilterFlags are the PR and chain filters shared by log, open and stats
+type FilterFlags struct {
+	Author        string   `help:"Filter by authors, comma-separated or @team from the config file (prefix with - to exclude)"`
+	ReviewStatus  string   `help:"Filter by review status (approved,fully-approved,pending,unapproved,changes-requested,all)" enum:"approved,fully-approved,pending,unapproved,changes-requested,all" default:"all"`
+	MinApprovals  int      `help:"Only PRs with at least this many approvals"`
+	Labels        []string `help:"Filter by labels (prefix with - to exclude)"`
+	LabelsMode    string   `help:"Whether PRs need any or all of the included labels (any,all)" enum:"any,all" default:"any"`
+	Reviewer      string   `help:"Filter by assigned reviewers, comma-separated or @team from the config file (prefix with - to exclude)"`
+	DraftStatus   string   `help:"Filter by draft status (draft,ready,all)" enum:"draft,ready,all" default:"all"`
+	Size          string   `help:"Filter by PR size (small,medium,large,all)" enum:"small,medium,large,all" default:"all"`
+	Mergeable     string   `help:"Filter by merge status (mergeable,conflicting,all)" enum:"mergeable,conflicting,all" default:"all"`
+	MergeState    string   `help:"Filter by GitHub's merge state (clean,blocked,behind,unstable,dirty,all)" enum:"clean,blocked,behind,unstable,dirty,all" default:"all"`
+	Checks        string   `help:"Filter by CI checks (pass,fail,pending,all)" enum:"pass,fail,pending,all" default:"all"`
+	UpdatedSince  string   `help:"Only PRs updated within a duration or since a date (e.g., 24h, 7d, 2026-10-01)"`
+	CreatedSince  string   `help:"Only PRs created within a duration or since a date (e.g., 24h, 7d, 2026-10-01)"`
+	UpdatedBefore string   `help:"Only PRs not updated within a duration or since a date (e.g., 14d, 2026-10-01)"`
+	CreatedBefore string   `help:"Only PRs created more than a duration ago or before a date (e.g., 30d, 2026-10-01)"`
+	Stale         bool     `help:"Only PRs not updated in the last two weeks, short for --updated-before 14d"`
+	Title         string   `help:"Filter by title, a glob or re: prefixed regex (prefix with - to exclude)"`
+	Head          string   `help:"Filter by head branch, a glob or re: prefixed regex (prefix with - to exclude)"`
+	Base          string   `help:"Filter by base branch, a glob or re: prefixed regex (prefix with - to exclude)"`
+	Where         string   `help:"Filter expression (e.g., \"author in (alice,bob) and not label:wip\")"`
+	ChainAll      string   `help:"Only keep chains where every PR matches, as key=value pairs of review, checks, mergeable, merge-state and draft (e.g. \"review=approved checks=pass\")"`
+	ChainAny      string   `help:"Only keep chains where at least one PR matches, same format as --chain-all"`
+	MinDepth      int      `help:"Only keep chains at least this many PRs deep"`
+	MaxDepth      int      `help:"Only keep chains at most this many PRs deep"`
+}
+
 var CLI struct {
 	Log struct {
-		Output       string   `help:"How to format the output (default,small,markdown,json)" enum:"default,small,markdown,json" default:"default"`
-		All          bool     `help:"Print all PRs and not just chains"`
-		Author       string   `help:"Filter by author (prefix with - to exclude)"`
-		ReviewStatus string   `help:"Filter by review status (approved,pending,unapproved,changes-requested,all)" enum:"approved,pending,unapproved,changes-requested,all" default:"all"`
-		Labels       []string `help:"Filter by labels (prefix with - to exclude)"`
-		Reviewer     string   `help:"Filter by assigned reviewer"`
-		DraftStatus  string   `help:"Filter by draft status (draft,ready,all)" enum:"draft,ready,all" default:"all"`
-		Size         string   `help:"Filter by PR size (small,medium,large,all)" enum:"small,medium,large,all" default:"all"`
-		Mergeable    string   `help:"Filter by merge status (mergeable,conflicting,all)" enum:"mergeable,conflicting,all" default:"all"`
-		Checks       string   `help:"Filter by CI checks (pass,fail,pending,all)" enum:"pass,fail,pending,all" default:"all"`
-		UpdatedSince string   `help:"Filter by last update time (e.g., 24h, 7d)"`
-		CreatedSince string   `help:"Filter by creation time (e.g., 24h, 7d)"`
+		Output string `help:"How to format the output (default,small,markdown,json,dot,mermaid,html,csv,tsv,ndjson)" enum:"default,small,markdown,json,dot,mermaid,html,csv,tsv,ndjson" default:"default"`
+		All    bool   `help:"Print all PRs and not just chains"`
+
+		FilterFlags `embed:""`
+
+		Format  string   `help:"Go template (or name of a template from the config file) used to print each PR"`
+		Columns []string `help:"Columns to include in csv, tsv and ndjson output (default: all)"`
+		Sort    string   `help:"Sort PRs at every level of the tree (number,created,updated,size,age,title)" enum:"none,number,created,updated,size,age,title" default:"none"`
+		GroupBy string   `help:"Print a section per group (author,label,reviewer,review-status), JSON output becomes a list of groups" enum:"none,author,label,reviewer,review-status" default:"none"`
+		Watch   string   `help:"Refresh every interval (e.g., 30s, 5m), highlighting changed PRs"`
+		Bell    bool     `help:"Ring the terminal bell when PRs change while watching"`
+		Notify  string   `help:"Shell command to run when PRs change while watching, gets a summary as $1"`
 	} `cmd:"" help:"Log PR chains" default:"1"`
 
 	Open struct {
-		Output       string   `help:"How to format the output (default,json)" enum:"default,json" default:"default"`
-		Filter       string   `arg:"" help:"Number or branch to select chain"`
-		Print        bool     `help:"Print URLs instead of opening"`
-		Author       string   `help:"Filter by author (prefix with - to exclude)"`
-		ReviewStatus string   `help:"Filter by review status (approved,pending,unapproved,changes-requested,all)" enum:"approved,pending,unapproved,changes-requested,all" default:"all"`
-		Labels       []string `help:"Filter by labels (prefix with - to exclude)"`
-		Reviewer     string   `help:"Filter by assigned reviewer"`
-		DraftStatus  string   `help:"Filter by draft status (draft,ready,all)" enum:"draft,ready,all" default:"all"`
-		Size         string   `help:"Filter by PR size (small,medium,large,all)" enum:"small,medium,large,all" default:"all"`
-		Mergeable    string   `help:"Filter by merge status (mergeable,conflicting,all)" enum:"mergeable,conflicting,all" default:"all"`
-		Checks       string   `help:"Filter by CI checks (pass,fail,pending,all)" enum:"pass,fail,pending,all" default:"all"`
-		UpdatedSince string   `help:"Filter by last update time (e.g., 24h, 7d)"`
-		CreatedSince string   `help:"Filter by creation time (e.g., 24h, 7d)"`
+		Output  string `help:"How to format the output (default,json)" enum:"default,json" default:"default"`
+		Filter  string `arg:"" optional:"" help:"Number or branch to select chain (default: pick one interactively)"`
+		Print   bool   `help:"Print URLs instead of opening"`
+		Browser string `help:"Command to open URLs with, %s is replaced by the URL (default: $BROWSER or the system's default)"`
+		View    string `help:"Tab of the PRs to open (conversation,files,commits,checks)" enum:"conversation,files,commits,checks" default:"conversation"`
+		Compare bool   `help:"Open a comparison of each PR's base and head branches instead"`
+		Only    string `help:"Open all PRs of the chain, only the current one, the first one not fully approved or the ones with failing checks (all,current,next-unapproved,failing)" enum:"current,next-unapproved,failing,all" default:"all"`
+
+		FilterFlags `embed:""`
 	} `cmd:"" help:"Open specific PR chain"`
 
 	Rebase struct {
 		Output string `help:"How to format the output (default,json)" enum:"default,json" default:"default"`
-		Filter string `arg:"" help:"Number or branch to select chain"`
+		Filter string `arg:"" optional:"" help:"Number or branch to select chain (default: pick one interactively)"`
 		Push   bool   `help:"Push changes to upstream"`
 		Args   string `help:"Extra args for pushing" default:"--force-with-lease"`
 		Run    bool   `help:"Run the commands instead of printing"`
 		Shell  string `help:"Shell for running commands" default:"$SHELL"`
 	} `cmd:"" help:"Rebase specific PR chain"`
 
-	Repo      string `help:"Repository to operate on (default: current)"`
+	Status struct {
+		Output string `help:"How to format the output (default,json)" enum:"default,json" default:"default"`
+		Filter string `arg:"" optional:"" help:"Number or branch to show the status of (default: current branch)"`
+	} `cmd:"" help:"Show the status of the current PR and its chain"`
+
+	Diff struct {
+		Output   string `help:"How to format the output (default,markdown,json)" enum:"default,markdown,json" default:"default"`
+		Author   string `help:"Filter by authors, comma-separated or @team from the config file (prefix with - to exclude)"`
+		NoUpdate bool   `help:"Don't save the current state as the new snapshot"`
+	} `cmd:"" help:"Show what changed since the last diff"`
+
+	Stats struct {
+		Output string `help:"How to format the output (default,json)" enum:"default,json" default:"default"`
+		All    bool   `help:"Include all PRs and not just chains"`
+
+		FilterFlags `embed:""`
+	} `cmd:"" help:"Print summary statistics for PR chains"`
+
+	Tui struct {
+		Filter  string `help:"Initial filter as key=value pairs (e.g. \"author=-me checks=fail\")"`
+		Browser string `help:"Command to open URLs with, %s is replaced by the URL (default: $BROWSER or the system's default)"`
+	} `cmd:"" help:"Browse PR chains interactively"`
+
+	Repo      string `help:"Repository (org/repo or host/org/repo) or repo group from the config file to operate on (default: current)"`
+	Preset    string `help:"Use a named set of flags from the config file"`
 	NoCache   bool   `help:"Ignore cache"`
 	CacheTime string `help:"Cache duration (e.g., 1m, 5m, 1h)" default:"1m"`
+	Record    string `help:"Save API responses to file for later replay" type:"path" xor:"api"`
+	Replay    string `help:"Use API responses saved with --record instead of GitHub" type:"path" xor:"api"`
 }
 
 func parseRepoURL(url string) (string, string, error) {
@@ -109,122 +162,471 @@ func getOriginURL() (string, error) {
 	return "", fmt.Errorf("no origin remote found in git (%v) or jj", err)
 }
 
-func getOrgRepo(arg string) (string, string, error) {
+// getCurrentBranch returns the checked out branch
+func getCurrentBranch() (string, error) {
+	// Try git first
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err == nil {
+		branch := strings.TrimSpace(string(out))
+		if branch != "HEAD" {
+			return branch, nil
+		}
+		err = fmt.Errorf("HEAD is detached")
+	}
+
+	// Fall back to jj, which keeps HEAD detached in colocated repos. Its
+	// working copy is usually a commit on top of the bookmark.
+	out, jjErr := exec.Command(
+		"jj", "log", "--no-graph", "-r", "latest(::@ & bookmarks())",
+		"-T", `local_bookmarks.map(|b| b.name()).join("\n")`,
+	).Output()
+	if jjErr != nil {
+		return "", fmt.Errorf("unable to read current branch from git (%v) or jj (%v)", err, jjErr)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if len(line) > 0 {
+			return line, nil
+		}
+	}
+
+	return "", fmt.Errorf("no current branch in git (%v) or bookmark in jj", err)
+}
+
+// getOrgRepo returns the host, org and repo to operate on. The repo is
+// either given as org/repo (on github.com) or host/org/repo, or taken
+// from the origin remote.
+func getOrgRepo(arg string) (string, string, string, error) {
 	if len(arg) > 0 {
 		splits := strings.Split(arg, "/")
-		if len(splits) != 2 {
-			return "", "", fmt.Errorf("unknown repo format: %s", arg)
+		switch len(splits) {
+		case 2:
+			return "github.com", splits[0], splits[1], nil
+		case 3:
+			return splits[0], splits[1], splits[2], nil
+		default:
+			return "", "", "", fmt.Errorf("unknown repo format: %s", arg)
 		}
-
-		return splits[0], splits[1], nil
 	}
 
 	url, err := getOriginURL()
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
+	}
+
+	org, repo, err := parseRepoURL(url)
+	return parseRepoHost(url), org, repo, err
+}
+
+// parseRepoHost returns the host of a git@ or https:// remote url
+func parseRepoHost(url string) string {
+	if strings.HasPrefix(url, "git@") {
+		host, _, _ := strings.Cut(strings.TrimPrefix(url, "git@"), ":")
+		return host
+	}
+
+	host, _, _ := strings.Cut(strings.TrimPrefix(url, "https://"), "/")
+	return host
+}
+
+// options builds the filters from the flags, checking them on the way
+func (f FilterFlags) options(teams map[string][]string) (FilterOptions, error) {
+	updatedBefore := f.UpdatedBefore
+	if f.Stale && len(updatedBefore) == 0 {
+		updatedBefore = staleAfter
+	}
+
+	opts := FilterOptions{
+		Author:        f.Author,
+		ReviewStatus:  f.ReviewStatus,
+		MinApprovals:  f.MinApprovals,
+		Labels:        f.Labels,
+		LabelsMode:    f.LabelsMode,
+		Reviewer:      f.Reviewer,
+		DraftStatus:   f.DraftStatus,
+		Size:          f.Size,
+		Mergeable:     f.Mergeable,
+		MergeState:    f.MergeState,
+		Checks:        f.Checks,
+		UpdatedSince:  f.UpdatedSince,
+		CreatedSince:  f.CreatedSince,
+		UpdatedBefore: updatedBefore,
+		CreatedBefore: f.CreatedBefore,
+	}
+
+	err := checkTimeFilters(opts)
+	if err != nil {
+		return opts, err
+	}
+	opts, err = withTeams(opts, teams)
+	if err != nil {
+		return opts, err
+	}
+	opts.Where, err = parseWhere(f.Where)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --where: %v", err)
+	}
+	opts, err = withPatternFilters(opts, f.Title, f.Head, f.Base)
+	if err != nil {
+		return opts, err
+	}
+	return withChainFilters(opts, f.ChainAll, f.ChainAny, f.MinDepth, f.MaxDepth)
+}
+
+// commandFilters builds the filters of the command from its flags
+func commandFilters(cmd string, teams map[string][]string) (FilterOptions, error) {
+	opts := FilterOptions{}
+	var err error
+	switch cmd {
+	case "log":
+		opts, err = CLI.Log.options(teams)
+		if err != nil {
+			return opts, err
+		}
+	case "open":
+		opts, err = CLI.Open.options(teams)
+		if err != nil {
+			return opts, err
+		}
+		if CLI.Open.Compare && CLI.Open.View != "conversation" {
+			return opts, fmt.Errorf("--compare can't be used with --view %s", CLI.Open.View)
+		}
+	case "diff":
+		opts, err = withTeams(FilterOptions{Author: CLI.Diff.Author}, teams)
+		if err != nil {
+			return opts, err
+		}
+	case "stats":
+		opts, err = CLI.Stats.options(teams)
+		if err != nil {
+			return opts, err
+		}
+	case "tui":
+		if _, err := parseTUIFilter(CLI.Tui.Filter); err != nil {
+			return opts, fmt.Errorf("invalid --filter: %v", err)
+		}
+	}
+
+	return opts.compile(), nil
+}
+
+// watchInterval parses --watch, returning 0 when not watching
+func watchInterval(watch string) (time.Duration, error) {
+	if len(watch) == 0 {
+		return 0, nil
+	}
+
+	interval, err := parseDuration(watch)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid watch interval '%s'", watch)
+	}
+	return interval, nil
+}
+
+// staleAfter is how long a PR has to go without updates for --stale
+const staleAfter = "14d"
+
+// checkTimeFilters makes sure that the time filters are durations or
+// dates, compileFilters ignores anything else
+func checkTimeFilters(opts FilterOptions) error {
+	for _, f := range [][2]string{
+		{"updated-since", opts.UpdatedSince},
+		{"created-since", opts.CreatedSince},
+		{"updated-before", opts.UpdatedBefore},
+		{"created-before", opts.CreatedBefore},
+	} {
+		if len(f[1]) == 0 {
+			continue
+		}
+		if _, _, err := parseTimeValue(f[1]); err != nil {
+			return fmt.Errorf("invalid --%s %q, %v", f[0], f[1], err)
+		}
+	}
+	return nil
+}
+
+// withChainFilters adds the chain filters to the options
+func withChainFilters(opts FilterOptions, chainAll, chainAny string, minDepth, maxDepth int) (FilterOptions, error) {
+	var err error
+	opts.ChainAll, err = parseChainFilter(chainAll)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --chain-all: %v", err)
+	}
+
+	opts.ChainAny, err = parseChainFilter(chainAny)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --chain-any: %v", err)
+	}
+
+	opts.MinDepth, opts.MaxDepth = minDepth, maxDepth
+	return opts, nil
+}
+
+// withPatternFilters adds the --title, --head and --base filters to the
+// options
+func withPatternFilters(opts FilterOptions, title, head, base string) (FilterOptions, error) {
+	for _, f := range [][2]string{{"title", title}, {"head", head}, {"base", base}} {
+		_, err := compilePattern(strings.TrimPrefix(f[1], "-"))
+		if err != nil {
+			return opts, fmt.Errorf("invalid --%s: %v", f[0], err)
+		}
 	}
 
-	return parseRepoURL(url)
+	opts.Title, opts.Head, opts.Base = title, head, base
+	return opts, nil
 }
 
-func buildFilterOptions(
-	author string,
-	reviewStatus string,
-	labels []string,
-	reviewer string,
-	draftStatus string,
-	size string,
-	mergeable string,
-	checks string,
-	updatedSince string,
-	createdSince string,
-) FilterOptions {
-	return FilterOptions{
-		Author:       author,
-		ReviewStatus: reviewStatus,
-		Labels:       labels,
-		Reviewer:     reviewer,
-		DraftStatus:  draftStatus,
-		Size:         size,
-		Mergeable:    mergeable,
-		Checks:       checks,
-		UpdatedSince: updatedSince,
-		CreatedSince: createdSince,
+// withTeams expands the @team entries of --author and --reviewer
+func withTeams(opts FilterOptions, teams map[string][]string) (FilterOptions, error) {
+	var err error
+	opts.Author, err = expandTeams(opts.Author, teams)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --author: %v", err)
+	}
+
+	opts.Reviewer, err = expandTeams(opts.Reviewer, teams)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --reviewer: %v", err)
 	}
+
+	return opts, nil
 }
 
+// errNoPRs is returned by run for repos without open PRs
+var errNoPRs = errors.New("No PRs and therefore no chains")
+
 func main() {
-	ctx := kong.Parse(&CLI)
-	cmd := ctx.Command()
+	config, warnings, err := loadConfigs(configPath(), localConfigPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, w := range warnings {
+		printProblems([]problem{{message: w}})
+	}
 
-	org, repo, err := getOrgRepo(CLI.Repo)
+	ctx := kong.Parse(&CLI, kong.Resolvers(configResolver{config: config}))
+	// Positional arguments are part of the command, e.g. "open <filter>"
+	cmd := strings.Fields(ctx.Command())[0]
+
+	err = config.checkPreset(CLI.Preset)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse the template before fetching so mistakes show up early
+	tmpl, err := parseFormat(CLI.Log.Format, config.Templates)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Same for the filters and the watch interval, to not fetch only to
+	// fail on a typo
+	opts, err := commandFilters(cmd, config.Teams)
+	if err != nil {
+		log.Fatal(err)
+	}
+	interval, err := watchInterval(CLI.Log.Watch)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	repos := []string{CLI.Repo}
+	if group, ok := config.Repos[CLI.Repo]; ok {
+		err := checkRepoGroup(cmd)
+		if err != nil {
+			log.Fatalf("Unable to use repo group %s: %v", CLI.Repo, err)
+		}
+		repos = group
+	}
+
+	for i, repoArg := range repos {
+		if len(repos) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			printRepoHeading(repoArg, cmd)
+		}
+
+		err := run(cmd, repoArg, config, tmpl, opts, interval)
+		if errors.Is(err, errNoPRs) {
+			fmt.Fprintln(os.Stderr, err)
+			if len(repos) == 1 {
+				os.Exit(1)
+			}
+			continue
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// checkRepoGroup makes sure that the command can be run for every repo
+// of a group, one after the other
+func checkRepoGroup(cmd string) error {
+	switch {
+	case len(CLI.Record) > 0 || len(CLI.Replay) > 0:
+		return fmt.Errorf("can't record or replay more than one repo")
+	case cmd == "stats" && CLI.Stats.Output == "default":
+		return nil
+	case cmd != "log":
+		return fmt.Errorf("%s only works on a single repo", cmd)
+	case len(CLI.Log.Watch) > 0:
+		return fmt.Errorf("can't watch more than one repo")
+	}
+
+	switch CLI.Log.Output {
+	case "default", "small", "markdown":
+		return nil
+	default:
+		return fmt.Errorf("--output %s only works on a single repo", CLI.Log.Output)
+	}
+}
+
+func printRepoHeading(repo string, cmd string) {
+	if cmd == "log" && CLI.Log.Output == "markdown" {
+		fmt.Printf("# %s\n\n", repo)
+		return
+	}
+
+	fmt.Println(color.New(color.Bold, color.Underline).Sprint(repo))
+}
+
+// run fetches the data for a repo and runs the command on it
+func run(cmd string, repoArg string, config Config, tmpl *template.Template, opts FilterOptions, interval time.Duration) error {
+	useCache := !CLI.NoCache
+
+	var rec Recording
+	if len(CLI.Replay) > 0 {
+		var err error
+		rec, err = readRecording(CLI.Replay)
+		if err != nil {
+			return err
+		}
+
+		if len(repoArg) == 0 {
+			repoArg = rec.Org + "/" + rec.Repo
+		}
+	}
+
+	host, org, repo, err := getOrgRepo(repoArg)
+	if err != nil {
+		return err
+	}
+
+	var provider Provider = newGitHubProvider(config.Hosts[host])
+	switch {
+	case len(CLI.Replay) > 0:
+		provider = replayProvider{recording: rec}
+		useCache = false
+	case len(CLI.Record) > 0:
+		provider = recordingProvider{provider: provider, path: CLI.Record}
+		useCache = false
+	}
+
 	var cacheTime time.Duration
-	if !CLI.NoCache {
+	if useCache {
 		var err error
 		cacheTime, err = time.ParseDuration(CLI.CacheTime)
 		if err != nil {
-			log.Fatalf("Invalid cache time format '%s': %v", CLI.CacheTime, err)
+			return fmt.Errorf("invalid cache time format '%s': %v", CLI.CacheTime, err)
 		}
 	}
 
-	data, err := getData(context.Background(), org, repo, !CLI.NoCache, cacheTime)
+	fetch := func() (data, error) {
+		return getData(context.Background(), provider, org, repo, useCache, cacheTime)
+	}
+
+	data, err := fetch()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if len(data.prs) == 0 {
-		fmt.Fprintln(os.Stderr, "No PRs and therefore no chains")
-		os.Exit(1)
+		return errNoPRs
 	}
 
+	// The current branch only makes sense for the repo we are in
+	local := len(CLI.Repo) == 0
+
 	switch cmd {
 	case "log":
-		opts := buildFilterOptions(
-			CLI.Log.Author,
-			CLI.Log.ReviewStatus,
-			CLI.Log.Labels,
-			CLI.Log.Reviewer,
-			CLI.Log.DraftStatus,
-
-			CLI.Log.Size,
-			CLI.Log.Mergeable,
-			CLI.Log.Checks,
-			CLI.Log.UpdatedSince,
-			CLI.Log.CreatedSince,
-		)
-		logChains(data, CLI.Log.All, opts)
-	case "open <filter>":
-		opts := buildFilterOptions(
-			CLI.Open.Author,
-			CLI.Open.ReviewStatus,
-			CLI.Open.Labels,
-			CLI.Open.Reviewer,
-			CLI.Open.DraftStatus,
-
-			CLI.Open.Size,
-			CLI.Open.Mergeable,
-			CLI.Open.Checks,
-			CLI.Open.UpdatedSince,
-			CLI.Open.CreatedSince,
-		)
-		openChain(data, CLI.Open.Filter, CLI.Open.Print, CLI.Open.Output, opts)
-	case "rebase <filter>":
-		err := rebaseChain(
+		if interval > 0 {
+			err = watchChains(data, fetch, CLI.Log.All, CLI.Log.Output, tmpl, opts, watchOptions{
+				interval: interval,
+				bell:     CLI.Log.Bell,
+				notify:   CLI.Log.Notify,
+				sort:     CLI.Log.Sort,
+				groupBy:  CLI.Log.GroupBy,
+			})
+			return err
+		}
+
+		err = logChains(data, org+"/"+repo, CLI.Log.All, tmpl, opts)
+		if err != nil {
+			return err
+		}
+	case "open":
+		filter, err := chainFilter(data, CLI.Open.Filter, local, opts)
+		if err != nil {
+			return err
+		}
+
+		err = openChain(data, filter, CLI.Open.Output, opts, openOptions{
+			print:   CLI.Open.Print,
+			browser: CLI.Open.Browser,
+			view:    CLI.Open.View,
+			compare: CLI.Open.Compare,
+			only:    CLI.Open.Only,
+		})
+		if err != nil {
+			return err
+		}
+	case "rebase":
+		filter, err := chainFilter(data, CLI.Rebase.Filter, local, FilterOptions{})
+		if err != nil {
+			return err
+		}
+
+		err = rebaseChain(
 			data,
-			CLI.Rebase.Filter,
+			filter,
 			CLI.Rebase.Push,
 			CLI.Rebase.Run,
 			CLI.Rebase.Args,
 			CLI.Rebase.Shell,
 			CLI.Rebase.Output)
 		if err != nil {
-			log.Fatal(err)
+			return err
+		}
+	case "status":
+		filter, err := chainFilter(data, CLI.Status.Filter, local, FilterOptions{})
+		if err != nil {
+			return err
+		}
+
+		err = printStatus(data, filter, CLI.Status.Output)
+		if err != nil {
+			return err
+		}
+	case "diff":
+		err = diffSnapshot(data, org, repo, CLI.Diff.Output, !CLI.Diff.NoUpdate, opts)
+		if err != nil {
+			return err
+		}
+	case "stats":
+		err = printStats(data, CLI.Stats.All, CLI.Stats.Output, opts)
+		if err != nil {
+			return err
+		}
+	case "tui":
+		err := runTUI(data, CLI.Tui.Filter, CLI.Tui.Browser)
+		if err != nil {
+			return err
 		}
 	default:
 		panic(cmd)
 	}
+
+	return nil
 }
@@ -2,9 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
-	"runtime"
+	"net/url"
 	"slices"
 	"strconv"
 )
@@ -16,10 +16,19 @@ func filterByNumber(d data, num int) []int {
 
 	prns := []int{}
 
+	// Cycles are broken by validateGraph, but keep track of what we
+	// have seen so that hand built mappings can't loop forever either.
+	seen := map[int]bool{num: true}
+
 	// items before
 	iter := num
 	for {
 		base := d.mappings[iter].base
+		if seen[base] {
+			break
+		}
+
+		seen[base] = true
 		prns = append([]int{base}, prns...)
 
 		if base == 0 {
@@ -39,11 +48,16 @@ func filterByNumber(d data, num int) []int {
 		last := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 		prns = append(prns, last)
-		following := d.mappings[last].following
+		following := slices.Clone(d.mappings[last].following)
 
 		if len(following) > 0 {
 			slices.Reverse(following)
-			stack = append(stack, following...)
+			for _, f := range following {
+				if !seen[f] {
+					seen[f] = true
+					stack = append(stack, f)
+				}
+			}
 		}
 	}
 
@@ -71,7 +85,62 @@ func filterChain(d data, filter string) []int {
 	return prns[1:]
 }
 
-func openChain(d data, filter string, print bool, output string, opts FilterOptions) {
+// openOptions are the settings for which PRs open opens and where
+type openOptions struct {
+	print   bool
+	browser string // command to open URLs with instead of the default
+	view    string // conversation, files, commits or checks
+	compare bool   // compare the base and head branches instead
+	only    string // current, next-unapproved or failing, all if empty
+}
+
+// prURL is the page for the PR to open
+func prURL(d data, p pr, view string, compare bool) string {
+	if compare {
+		// Branch names can hold characters like # which would end the path
+		return fmt.Sprintf("%s/compare/%s...%s", d.url, url.PathEscape(p.base), url.PathEscape(p.head))
+	}
+
+	link := fmt.Sprintf("%s/pull/%d", d.url, p.number)
+	if view != "conversation" && len(view) > 0 {
+		link += "/" + view
+	}
+	return link
+}
+
+// selectPRs narrows down the PRs of a chain, parents first, to the ones
+// --only asks for. The filter picks the current PR.
+func selectPRs(d data, prns []int, filter string, only string) []int {
+	current, err := strconv.Atoi(filter)
+	if err != nil {
+		current = d.branch[filter]
+	}
+
+	selected := []int{}
+	for _, n := range prns {
+		p := d.prs[n]
+		switch only {
+		case "current":
+			if n == current {
+				selected = append(selected, n)
+			}
+		case "next-unapproved":
+			if !fullyApproved(p) {
+				return []int{n}
+			}
+		case "failing":
+			if filterFields["checks"].is(p, "fail") {
+				selected = append(selected, n)
+			}
+		default:
+			selected = append(selected, n)
+		}
+	}
+
+	return selected
+}
+
+func openChain(d data, filter string, output string, opts FilterOptions, oo openOptions) error {
 	prns := filterChain(d, filter)
 	if len(prns) == 0 {
 		if output == "json" {
@@ -81,10 +150,16 @@ func openChain(d data, filter string, print bool, output string, opts FilterOpti
 		} else {
 			fmt.Println("No PR chain found with filter")
 		}
-		return
+		return nil
 	}
 
-	prns = FilterPRNumbers(d, prns, opts)
+	// Chain filters look at the whole chain like they do for log, not
+	// just the parents and children of the selected PR
+	if chainMatches(d, d.mappings, chainPRs(d.mappings, prns[0]), opts) {
+		prns = FilterPRNumbers(d, prns, opts)
+	} else {
+		prns = []int{}
+	}
 
 	if len(prns) == 0 {
 		if output == "json" {
@@ -94,67 +169,57 @@ func openChain(d data, filter string, print bool, output string, opts FilterOpti
 		} else {
 			fmt.Println("No PR chain found matching the filters")
 		}
-		return
+		return nil
+	}
+
+	prns = selectPRs(d, prns, filter, oo.only)
+	if len(prns) == 0 {
+		if output == "json" {
+			jsonOutput := JSONOutput{Chains: []JSONChain{}}
+			outputBytes, _ := json.MarshalIndent(jsonOutput, "", "  ")
+			fmt.Println(string(outputBytes))
+		} else {
+			fmt.Printf("No %s PR in the chain\n", oo.only)
+		}
+		return nil
 	}
 
 	if output == "json" {
 		chains := []JSONChain{}
 		for _, prNum := range prns {
 			p := d.prs[prNum]
-			jsonPR := JSONPullRequest{
-				Number:              p.number,
-				Base:                p.base,
-				Head:                p.head,
-				Title:               p.title,
-				Author:              p.author,
-				ApprovedBy:          p.approvedBy,
-				HasChangesRequested: p.hasChangesRequested,
-				HasComments:         p.hasComments,
-				Labels:              p.labels,
-				IsDraft:             p.isDraft,
-				CreatedAt:           p.createdAt,
-				Reviewers:           p.reviewers,
-				Additions:           p.additions,
-				Deletions:           p.deletions,
-				URL:                 fmt.Sprintf("%s/pull/%d", d.url, p.number),
-			}
-			chains = append(chains, JSONChain{PullRequest: jsonPR, Children: []JSONChain{}})
+			jsonPR := toJSONPullRequest(p, d.url)
+			// Point at the page that would have been opened
+			jsonPR.URL = prURL(d, p, oo.view, oo.compare)
+			chains = append(chains, JSONChain{
+				PullRequest: jsonPR,
+				Status:      jsonPR.Status,
+				BlockedBy:   chainBlockedBy(p, nil),
+				Children:    []JSONChain{},
+			})
 		}
 		jsonOutput := JSONOutput{Chains: chains}
 		outputBytes, _ := json.MarshalIndent(jsonOutput, "", "  ")
 		fmt.Println(string(outputBytes))
 	} else {
+		urls := []string{}
 		for _, p := range prns {
-			if print {
-				fmt.Println(fmt.Sprintf("%s/pull/%d", d.url, p))
-			} else {
-				openBrowser(fmt.Sprintf("%s/pull/%d", d.url, p))
-			}
+			urls = append(urls, prURL(d, d.prs[p], oo.view, oo.compare))
 		}
-	}
-}
 
-// openBrowser is a simple cross platform way to open the url in the
-// default browser. It uses the default opening commands for each platform.
-// NOTE: If the platform is not supported, it will just print the url
-// to the console.
-func openBrowser(url string) error {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
-	case "darwin":
-		cmd = exec.Command("open", url)
-	default:
-		fmt.Println(url)
-	}
+		if oo.print {
+			for _, url := range urls {
+				fmt.Println(url)
+			}
+			return nil
+		}
 
-	if cmd != nil {
-		fmt.Println("Opening", url)
-		return cmd.Run()
+		err := openBrowser(urls, oo.browser)
+		if errors.Is(err, errNoBrowser) {
+			printURLs(urls)
+			return nil
+		}
+		return err
 	}
 
 	return nil
@@ -1,6 +1,13 @@
 package main
 
-import "testing"
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
 
 func TestFilterByNumber(t *testing.T) {
 	table := []struct {
@@ -74,3 +81,140 @@ func TestFilterByNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestPrURL(t *testing.T) {
+	d := makeTestData(map[int]pr{}, map[int]mapping{})
+	p := pr{number: 2, base: "one", head: "two"}
+
+	tests := []struct {
+		view    string
+		compare bool
+		want    string
+	}{
+		{"conversation", false, "https://github.com/test/repo/pull/2"},
+		{"files", false, "https://github.com/test/repo/pull/2/files"},
+		{"commits", false, "https://github.com/test/repo/pull/2/commits"},
+		{"checks", false, "https://github.com/test/repo/pull/2/checks"},
+		{"conversation", true, "https://github.com/test/repo/compare/one...two"},
+	}
+
+	for _, tt := range tests {
+		if got := prURL(d, p, tt.view, tt.compare); got != tt.want {
+			t.Errorf("prURL(%q, %v) = %q, want %q", tt.view, tt.compare, got, tt.want)
+		}
+	}
+
+	p = pr{number: 2, base: "release#1", head: "fix?x"}
+	want := "https://github.com/test/repo/compare/release%231...fix%3Fx"
+	if got := prURL(d, p, "conversation", true); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSelectPRs(t *testing.T) {
+	d := tuiTestData()
+	d.branch = map[string]int{"two": 2}
+	prns := filterChain(d, "2")
+
+	tests := []struct {
+		filter string
+		only   string
+		want   []int
+	}{
+		{"2", "all", []int{1, 2, 3}},
+		{"2", "current", []int{2}},
+		{"two", "current", []int{2}},
+		// #1 has an approval, which is enough without branch protection
+		{"2", "next-unapproved", []int{2}},
+		{"2", "failing", []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter+" "+tt.only, func(t *testing.T) {
+			if got := selectPRs(d, prns, tt.filter, tt.only); !slices.Equal(got, tt.want) {
+				t.Errorf("selectPRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// captureOpen runs openChain and returns what it printed
+func captureOpen(t *testing.T, d data, filter, output string, opts FilterOptions, oo openOptions) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = openChain(d, filter, output, opts, oo)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestOpenChain_ChainFilters(t *testing.T) {
+	// 0 -> 1 -> 2
+	//        -> 3
+	d := makeTestData(
+		map[int]pr{
+			1: {number: 1, checksState: "success"},
+			2: {number: 2, checksState: "failure"},
+			3: {number: 3, checksState: "success"},
+		},
+		map[int]mapping{
+			0: {following: []int{1}},
+			1: {base: 0, following: []int{2, 3}},
+			2: {base: 1, following: []int{}},
+			3: {base: 1, following: []int{}},
+		},
+	)
+
+	open := func(opts FilterOptions) string {
+		t.Helper()
+		return captureOpen(t, d, "3", "default", opts, openOptions{print: true})
+	}
+
+	// #2 isn't on the path to #3 but is part of the chain, as in log
+	if out := open(FilterOptions{ChainAll: &FilterOptions{Checks: "pass"}}); !strings.Contains(out, "No PR chain found matching the filters") {
+		t.Errorf("expected the chain to be dropped, got %q", out)
+	}
+	if out := open(FilterOptions{ChainAny: &FilterOptions{Checks: "fail"}}); !strings.Contains(out, "/pull/3") {
+		t.Errorf("expected the chain to be kept, got %q", out)
+	}
+}
+
+func TestOpenChain_JSONURL(t *testing.T) {
+	d := tuiTestData()
+
+	tests := []struct {
+		oo   openOptions
+		want string
+	}{
+		{openOptions{view: "conversation"}, "https://github.com/test/repo/pull/1"},
+		{openOptions{view: "checks"}, "https://github.com/test/repo/pull/1/checks"},
+		{openOptions{view: "conversation", compare: true}, "https://github.com/test/repo/compare/main...one"},
+	}
+
+	for _, tt := range tests {
+		out := captureOpen(t, d, "1", "json", FilterOptions{}, tt.oo)
+
+		var output JSONOutput
+		if err := json.Unmarshal([]byte(out), &output); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, out)
+		}
+		if len(output.Chains) == 0 || output.Chains[0].PullRequest.URL != tt.want {
+			t.Errorf("%+v: expected the first URL to be %q, got:\n%s", tt.oo, tt.want, out)
+		}
+		// Same fields as in log
+		if len(output.Chains) < 2 || output.Chains[1].PullRequest.ChecksState != "failure" {
+			t.Errorf("%+v: expected the checks state of #2, got:\n%s", tt.oo, out)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// errNoChainSelected is returned when the picker is closed without
+// choosing a chain
+var errNoChainSelected = errors.New("no chain selected")
+
+// errNotTerminal is returned when there is no terminal to pick a chain on
+var errNotTerminal = errors.New("no PR number or branch given and not running in a terminal to pick a chain")
+
+// pickerItem is a chain in the picker
+type pickerItem struct {
+	number int    // root PR of the chain
+	label  string // what is shown
+	text   string // what the query is matched against
+}
+
+// picker holds the state of the fuzzy chain picker. Like tui it does no
+// IO itself, see runPicker for that.
+type picker struct {
+	prompt  string
+	items   []pickerItem
+	query   string
+	matches []int // indices into items, best match first
+	cursor  int
+}
+
+func newPicker(d data, opts FilterOptions, prompt string) *picker {
+	p := &picker{prompt: prompt}
+
+	for _, n := range visibleTree(d, filterChains(d.mappings), 0, opts) {
+		root := d.prs[n.number]
+		item := pickerItem{number: n.number}
+
+		count := 0
+		var walk func(n treeNode)
+		walk = func(n treeNode) {
+			pr := d.prs[n.number]
+			count++
+			item.text += fmt.Sprintf("#%d %s %s %s\n", pr.number, pr.title, pr.head, pr.author)
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+		walk(n)
+
+		item.label = fmt.Sprintf("#%d %s [%s] (%s)", root.number, root.title, root.head, root.author)
+		if count > 1 {
+			item.label += fmt.Sprintf(" +%d", count-1)
+		}
+		p.items = append(p.items, item)
+	}
+
+	p.filter()
+	return p
+}
+
+// fuzzyScore matches every space separated word of the query as a
+// subsequence of the text, ignoring case. Consecutive characters and
+// ones at the start of a word score higher. It returns -1 if there is
+// no match.
+func fuzzyScore(query, text string) int {
+	text = strings.ToLower(text)
+	score := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		best := -1
+
+		// Try every starting point, the first one isn't always the best
+		for start := range text {
+			s, ok := subsequenceScore(word, text[start:], start == 0 || !isWordChar(rune(text[start-1])))
+			if ok && s > best {
+				best = s
+			}
+		}
+
+		if best < 0 {
+			return -1
+		}
+		score += best
+	}
+	return score
+}
+
+func subsequenceScore(word, text string, wordStart bool) (int, bool) {
+	if len(word) == 0 || len(text) == 0 || text[0] != word[0] {
+		return 0, false
+	}
+
+	score, i, prev := 0, 0, -2
+	for j := 0; j < len(text) && i < len(word); j++ {
+		if text[j] != word[i] {
+			continue
+		}
+
+		score++
+		if j == prev+1 {
+			score += 2
+		}
+		if (j == 0 && wordStart) || (j > 0 && !isWordChar(rune(text[j-1]))) {
+			score += 3
+		}
+		prev = j
+		i++
+	}
+
+	return score, i == len(word)
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// filter updates the matches for the current query
+func (p *picker) filter() {
+	scores := map[int]int{}
+	p.matches = []int{}
+	for i, item := range p.items {
+		if s := fuzzyScore(p.query, item.text); s >= 0 {
+			scores[i] = s
+			p.matches = append(p.matches, i)
+		}
+	}
+
+	slices.SortStableFunc(p.matches, func(a, b int) int { return scores[b] - scores[a] })
+	p.cursor = 0
+}
+
+// handleKey updates the state for a key press. It returns true once
+// done, along with the root PR of the chosen chain or 0 if cancelled.
+func (p *picker) handleKey(key string) (bool, int) {
+	switch key {
+	case "esc", "ctrl-c":
+		return true, 0
+	case "enter":
+		if len(p.matches) == 0 {
+			return false, 0
+		}
+		return true, p.items[p.matches[p.cursor]].number
+	case "down", "\x0e": // ctrl-n
+		p.cursor = min(p.cursor+1, max(len(p.matches)-1, 0))
+	case "up", "\x10": // ctrl-p
+		p.cursor = max(p.cursor-1, 0)
+	case "backspace":
+		if len(p.query) > 0 {
+			r := []rune(p.query)
+			p.query = string(r[:len(r)-1])
+			p.filter()
+		}
+	case "left", "right":
+	default:
+		// Pasted text arrives as a single read
+		if strings.IndexFunc(key, func(r rune) bool { return !unicode.IsPrint(r) }) < 0 {
+			p.query += key
+			p.filter()
+		}
+	}
+
+	return false, 0
+}
+
+func (p *picker) render(width, height int) []string {
+	lines := []string{fit(fmt.Sprintf("%s: %s", p.prompt, p.query), width)}
+
+	// Keep the cursor in view
+	rows := max(height-2, 1)
+	offset := max(p.cursor-rows+1, 0)
+
+	for i := offset; i < len(p.matches) && i < offset+rows; i++ {
+		prefix := "  "
+		if i == p.cursor {
+			prefix = "> "
+		}
+
+		line := fit(prefix+p.items[p.matches[i]].label, width)
+		if i == p.cursor {
+			line = "\x1b[7m" + line + "\x1b[27m"
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, fit(fmt.Sprintf("%d/%d  type to search  ↑/↓ move  enter select  esc cancel", len(p.matches), len(p.items)), width))
+
+	return lines
+}
+
+// chainFilter returns the filter for open, rebase and status. If none
+// was given the current branch is used when operating on the current
+// repo, falling back to letting the user pick a chain.
+func chainFilter(d data, filter string, local bool, opts FilterOptions) (string, error) {
+	if len(filter) > 0 {
+		return filter, nil
+	}
+
+	prompt := "Select a chain"
+	var noPR error
+	if local {
+		branch, err := getCurrentBranch()
+		if err == nil {
+			filter, noPR = branchFilter(d, branch)
+			if noPR == nil {
+				return filter, nil
+			}
+			prompt = fmt.Sprintf("No PR for %s, select a chain", branch)
+		}
+	}
+
+	number, err := runPicker(d, opts, prompt)
+	if errors.Is(err, errNotTerminal) && noPR != nil {
+		return "", noPR
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(number), nil
+}
+
+// branchFilter makes sure that there is a PR for the branch, explaining
+// what to do if there isn't
+func branchFilter(d data, branch string) (string, error) {
+	if branch == d.defaultBranch {
+		return "", fmt.Errorf("%s is the default branch, check out a branch with a PR or pass a PR number or branch", branch)
+	}
+
+	if d.branch[branch] == 0 {
+		return "", fmt.Errorf("no open PR for the current branch %s in %s, push it and open one or pass a PR number or branch", branch, d.url)
+	}
+
+	return branch, nil
+}
+
+// runPicker lets the user choose a chain on the terminal, returning the
+// root PR of the chain. The picker is drawn on stderr so that stdout
+// can still be piped, e.g. into sh for rebase.
+func runPicker(d data, opts FilterOptions, prompt string) (int, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stderr.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return 0, errNotTerminal
+	}
+
+	p := newPicker(d, opts, prompt)
+	if len(p.items) == 0 {
+		return 0, fmt.Errorf("no PR chains to pick from")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Fprint(os.Stderr, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(os.Stderr, "\x1b[?25h\x1b[?1049l")
+		term.Restore(in, state)
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		width, height, _ := term.GetSize(out)
+		var sb strings.Builder
+		for i, line := range p.render(width, height) {
+			fmt.Fprintf(&sb, "\x1b[%d;1H%s\x1b[K", i+1, line)
+		}
+		fmt.Fprint(os.Stderr, sb.String())
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+
+		if done, number := p.handleKey(parseKey(buf[:n])); done {
+			if number == 0 {
+				return 0, errNoChainSelected
+			}
+			return number, nil
+		}
+	}
+}
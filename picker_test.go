@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func pickerNumbers(p *picker) []int {
+	nums := []int{}
+	for _, m := range p.matches {
+		nums = append(nums, p.items[m].number)
+	}
+	return nums
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		match bool
+	}{
+		{"", "anything", true},
+		{"one", "#1 One one alice", true},
+		{"oe", "#1 One one alice", true},
+		{"eno", "#1 One one alice", false},
+		{"one alice", "#1 One one alice", true},
+		{"one bob", "#1 One one alice", false},
+		{"#4", "#4 Four four carol", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := fuzzyScore(tt.query, tt.text) >= 0; got != tt.match {
+				t.Errorf("fuzzyScore(%q, %q) matched %v, want %v", tt.query, tt.text, got, tt.match)
+			}
+		})
+	}
+
+	// Word starts and consecutive characters rank higher
+	if fuzzyScore("gc", "group cli") <= fuzzyScore("gc", "garbage") {
+		t.Errorf("expected word start matches to score higher")
+	}
+	if fuzzyScore("fiv", "five") <= fuzzyScore("fiv", "fix overview") {
+		t.Errorf("expected consecutive matches to score higher")
+	}
+}
+
+func TestPicker(t *testing.T) {
+	p := newPicker(tuiTestData(), FilterOptions{}, "Select a chain")
+
+	// Standalone PRs aren't chains
+	if got := pickerNumbers(p); len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Fatalf("unexpected chains %v", got)
+	}
+
+	// Children of the chain are searched too
+	for _, k := range []string{"f", "i", "v", "e"} {
+		p.handleKey(k)
+	}
+	if got := pickerNumbers(p); len(got) != 1 || got[0] != 4 {
+		t.Errorf("expected only #4 to match %q, got %v", p.query, got)
+	}
+
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	if len(p.matches) != 2 {
+		t.Errorf("expected all chains after clearing the query, got %v", pickerNumbers(p))
+	}
+
+	// Pasted text is added as is
+	p.handleKey("carol")
+	if p.query != "carol" {
+		t.Errorf("unexpected query %q", p.query)
+	}
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+	p.handleKey("backspace")
+
+	p.handleKey("down")
+	p.handleKey("down")
+	if done, n := p.handleKey("enter"); !done || n != 4 {
+		t.Errorf("expected #4 to be picked, got %v %d", done, n)
+	}
+
+	p.handleKey("zzz")
+	if done, _ := p.handleKey("enter"); done {
+		t.Errorf("expected enter to do nothing without matches")
+	}
+	if done, n := p.handleKey("esc"); !done || n != 0 {
+		t.Errorf("expected esc to cancel, got %v %d", done, n)
+	}
+}
+
+func TestPicker_Render(t *testing.T) {
+	p := newPicker(tuiTestData(), FilterOptions{}, "Select a chain")
+	lines := p.render(80, 6)
+
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[1], "> #1 One [one] (alice) +2") {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "#4 Four [four] (carol) +1") {
+		t.Errorf("unexpected second row %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[5], "2/2") {
+		t.Errorf("unexpected footer %q", lines[5])
+	}
+}
+
+func TestChainFilter(t *testing.T) {
+	// A filter that was passed is used as is, without a terminal
+	got, err := chainFilter(tuiTestData(), "four", true, FilterOptions{})
+	if err != nil || got != "four" {
+		t.Errorf("chainFilter() = %q, %v", got, err)
+	}
+}
+
+func TestBranchFilter(t *testing.T) {
+	d := tuiTestData()
+	d.branch = map[string]int{"one": 1, "four": 4}
+
+	tests := []struct {
+		branch string
+		err    string
+	}{
+		{"four", ""},
+		{"main", "main is the default branch"},
+		{"wip", "no open PR for the current branch wip in https://github.com/test/repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, err := branchFilter(d, tt.branch)
+			if len(tt.err) == 0 {
+				if err != nil || got != tt.branch {
+					t.Errorf("branchFilter(%q) = %q, %v", tt.branch, got, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
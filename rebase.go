@@ -31,6 +31,41 @@ func rebaseChain(
 		return nil
 	}
 
+	script, commands := rebaseScript(d, prns, push, args)
+
+	if output == "json" {
+		jsonOutput := JSONRebaseOutput{
+			Script:   script,
+			Commands: commands,
+		}
+		outputBytes, _ := json.MarshalIndent(jsonOutput, "", "  ")
+		fmt.Println(string(outputBytes))
+		return nil
+	}
+
+	if run {
+		if shell == "$SHELL" {
+			shell = os.Getenv("SHELL")
+			if len(shell) == 0 {
+				shell = "/bin/sh"
+			}
+		}
+
+		err := execScript(script, shell)
+		if err != nil {
+			return fmt.Errorf("unable to exec script: %v", err)
+		}
+
+		return nil
+	}
+
+	fmt.Println(script)
+	return nil
+}
+
+// rebaseScript generates the script rebasing the given chain onto the
+// default branch along with the individual commands in it.
+func rebaseScript(d data, prns []int, push bool, args string) (string, []string) {
 	// Find leaf branches (PRs with no children in the chain)
 	prnSet := make(map[int]bool, len(prns))
 	for _, p := range prns {
@@ -75,36 +110,7 @@ func rebaseChain(
 		}
 	}
 
-	script := strings.Join(lines, "\n")
-
-	if output == "json" {
-		jsonOutput := JSONRebaseOutput{
-			Script:   script,
-			Commands: commands,
-		}
-		outputBytes, _ := json.MarshalIndent(jsonOutput, "", "  ")
-		fmt.Println(string(outputBytes))
-		return nil
-	}
-
-	if run {
-		if shell == "$SHELL" {
-			shell = os.Getenv("SHELL")
-			if len(shell) == 0 {
-				shell = "/bin/sh"
-			}
-		}
-
-		err := execScript(script, shell)
-		if err != nil {
-			return fmt.Errorf("unable to exec script: %v", err)
-		}
-
-		return nil
-	}
-
-	fmt.Println(script)
-	return nil
+	return strings.Join(lines, "\n"), commands
 }
 
 func execScript(script, shell string) error {
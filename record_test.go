@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	f := newFakeGitHub(t)
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recorder := recordingProvider{provider: githubProvider{url: f.URL}, path: path}
+	recorded, err := getData(context.Background(), recorder, "test", "repo", false, 0)
+	if err != nil {
+		t.Fatalf("unexpected error while recording: %v", err)
+	}
+
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("recording not written: %v", err)
+	}
+	// Fields that chainlink does not decode are dropped
+	if strings.Contains(string(bts), `"state": "OPEN"`) {
+		t.Error("expected unused fields to be stripped from recording")
+	}
+
+	rec, err := readRecording(path)
+	if err != nil {
+		t.Fatalf("unable to read recording: %v", err)
+	}
+	if rec.Org != "test" || rec.Repo != "repo" {
+		t.Errorf("unexpected repo in recording %s/%s", rec.Org, rec.Repo)
+	}
+
+	replayed, err := getData(context.Background(), replayProvider{recording: rec}, "test", "repo", false, 0)
+	if err != nil {
+		t.Fatalf("unexpected error while replaying: %v", err)
+	}
+
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed data differs from recorded data\nrecorded: %+v\nreplayed: %+v", recorded, replayed)
+	}
+}
+
+func TestReplay_RepoMismatch(t *testing.T) {
+	p := replayProvider{recording: Recording{Org: "test", Repo: "repo"}}
+
+	_, err := p.Fetch(context.Background(), "other", "repo")
+	if err == nil {
+		t.Fatal("expected error for mismatched repo")
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is the state of a repository's PRs as last seen by diff
+type Snapshot struct {
+	TakenAt      time.Time         `json:"takenAt"`
+	PullRequests []JSONPullRequest `json:"pullRequests"`
+}
+
+// snapshotPath returns where the snapshot for a repository is kept.
+// Unlike the cache it has to survive reboots, so it lives in
+// $XDG_STATE_HOME (~/.local/state by default).
+func snapshotPath(org, repo string) string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "chainlink", "snapshots", org, repo+".json")
+}
+
+// readSnapshot returns the saved snapshot and whether there was one
+func readSnapshot(path string) (Snapshot, bool, error) {
+	snap := Snapshot{}
+
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return snap, false, nil
+	}
+	if err != nil {
+		return snap, false, fmt.Errorf("unable to read snapshot: %v", err)
+	}
+
+	err = json.Unmarshal(bts, &snap)
+	if err != nil {
+		return snap, false, fmt.Errorf("invalid snapshot %s: %v", path, err)
+	}
+
+	return snap, true, nil
+}
+
+func writeSnapshot(path string, d data) error {
+	snap := Snapshot{TakenAt: time.Now().UTC(), PullRequests: []JSONPullRequest{}}
+
+	nums := []int{}
+	for n := range d.prs {
+		nums = append(nums, n)
+	}
+	slices.Sort(nums)
+	for _, n := range nums {
+		snap.PullRequests = append(snap.PullRequests, toJSONPullRequest(d.prs[n], d.url))
+	}
+
+	bts, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to create snapshot dir: %v", err)
+	}
+
+	return os.WriteFile(path, bts, 0644)
+}
+
+// snapshotPRs turns a snapshot back into PRs so that it can be diffed
+// against fresh data.
+func snapshotPRs(snap Snapshot) map[int]pr {
+	prs := map[int]pr{}
+	for _, j := range snap.PullRequests {
+		prs[j.Number] = pr{
+			number:              j.Number,
+			base:                j.Base,
+			head:                j.Head,
+			title:               j.Title,
+			author:              j.Author,
+			approvers:           snapshotApprovers(j),
+			requiredApprovals:   j.RequiredApprovals,
+			hasChangesRequested: j.HasChangesRequested,
+			hasComments:         j.HasComments,
+			labels:              j.Labels,
+			isDraft:             j.IsDraft,
+			createdAt:           j.CreatedAt,
+			updatedAt:           j.UpdatedAt,
+			mergeable:           j.Mergeable,
+			mergeState:          j.MergeState,
+			reviewDecision:      j.ReviewDecision,
+			checksState:         j.ChecksState,
+			reviewers:           j.Reviewers,
+			additions:           j.Additions,
+			deletions:           j.Deletions,
+		}
+	}
+	return prs
+}
+
+// snapshotApprovers returns the approvers of a snapshotted PR. Snapshots
+// taken before all approvers were tracked only have approvedBy.
+func snapshotApprovers(j JSONPullRequest) []string {
+	if len(j.Approvers) == 0 && len(j.ApprovedBy) > 0 {
+		return []string{j.ApprovedBy}
+	}
+	return j.Approvers
+}
+
+// chainRoots maps every PR to the first PR of its chain by following
+// base branches, the same way getData links PRs.
+func chainRoots(prs map[int]pr) map[int]int {
+	heads := map[string]int{}
+	for n, p := range prs {
+		heads[p.head] = n
+	}
+
+	roots := map[int]int{}
+	for n := range prs {
+		root := n
+		seen := map[int]bool{n: true}
+		for {
+			parent, ok := heads[prs[root].base]
+			if !ok || seen[parent] {
+				break
+			}
+			seen[parent] = true
+			root = parent
+		}
+		roots[n] = root
+	}
+
+	return roots
+}
+
+// chainChanges is the changes within a single chain
+type chainChanges struct {
+	root    pr
+	changes []prChange
+}
+
+// groupChanges groups changes by chain, ordered by the root PR. PRs
+// which are gone are placed in the chain they were part of before.
+func groupChanges(prev, cur map[int]pr, changes []prChange) []chainChanges {
+	prevRoots, curRoots := chainRoots(prev), chainRoots(cur)
+
+	byRoot := map[int]*chainChanges{}
+	roots := []int{}
+	for _, c := range changes {
+		root, prs := curRoots[c.number], cur
+		if c.field == "removed" {
+			root, prs = prevRoots[c.number], prev
+		}
+
+		if _, ok := byRoot[root]; !ok {
+			byRoot[root] = &chainChanges{root: prs[root]}
+			roots = append(roots, root)
+		}
+		byRoot[root].changes = append(byRoot[root].changes, c)
+	}
+
+	slices.Sort(roots)
+	grouped := []chainChanges{}
+	for _, r := range roots {
+		grouped = append(grouped, *byRoot[r])
+	}
+
+	return grouped
+}
+
+// diffSnapshot reports the changes to the PRs since the saved snapshot
+// and saves the current state for next time.
+func diffSnapshot(d data, org, repo string, output string, update bool, opts FilterOptions) error {
+	path := snapshotPath(org, repo)
+	snap, found, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	if update {
+		defer func() {
+			err := writeSnapshot(path, d)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Unable to save snapshot", err)
+			}
+		}()
+	}
+
+	if !found {
+		if output == "json" {
+			fmt.Println(`{"since": null, "chains": []}`)
+		} else {
+			fmt.Println("No previous snapshot, run again later to see what changed")
+		}
+		return nil
+	}
+
+	prev := snapshotPRs(snap)
+
+	changes := []prChange{}
+	for _, c := range diffPRs(prev, d.prs) {
+		p := d.prs[c.number]
+		if c.field == "removed" {
+			p = prev[c.number]
+		}
+		if ApplyPRFilters(p, opts) {
+			changes = append(changes, c)
+		}
+	}
+
+	grouped := groupChanges(prev, d.prs, changes)
+	title := func(c prChange) string {
+		if c.field == "removed" {
+			return prev[c.number].title
+		}
+		return d.prs[c.number].title
+	}
+
+	switch output {
+	case "json":
+		jsonOutput := JSONDiffOutput{Since: &snap.TakenAt, Chains: []JSONDiffChain{}}
+		for _, g := range grouped {
+			chain := JSONDiffChain{Root: g.root.number, Title: g.root.title, Changes: []JSONChange{}}
+			for _, c := range g.changes {
+				chain.Changes = append(chain.Changes, JSONChange{
+					Number: c.number,
+					Title:  title(c),
+					Field:  c.field,
+					From:   c.from,
+					To:     c.to,
+				})
+			}
+			jsonOutput.Chains = append(jsonOutput.Chains, chain)
+		}
+		outputBytes, _ := json.MarshalIndent(jsonOutput, "", "  ")
+		fmt.Println(string(outputBytes))
+	case "markdown":
+		if len(grouped) == 0 {
+			fmt.Printf("No changes since %s\n", snap.TakenAt.Local().Format("2006-01-02 15:04"))
+			return nil
+		}
+
+		lines := []string{}
+		for _, g := range grouped {
+			lines = append(lines, fmt.Sprintf("### [#%d](%s/pull/%d) %s", g.root.number, d.url, g.root.number, g.root.title))
+			for _, c := range g.changes {
+				lines = append(lines, fmt.Sprintf("- [#%d](%s/pull/%d) %s: %s", c.number, d.url, c.number, title(c), c.describe()))
+			}
+			lines = append(lines, "")
+		}
+		fmt.Print(strings.Join(lines, "\n"))
+	default:
+		if len(grouped) == 0 {
+			fmt.Printf("No changes since %s\n", snap.TakenAt.Local().Format("2006-01-02 15:04"))
+			return nil
+		}
+
+		for _, g := range grouped {
+			fmt.Printf("#%d %s\n", g.root.number, g.root.title)
+			for _, c := range g.changes {
+				fmt.Printf("  #%d %s: %s\n", c.number, title(c), c.describe())
+			}
+		}
+	}
+
+	return nil
+}
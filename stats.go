@@ -0,0 +1,128 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// blockedOnCI is true for PRs whose checks haven't passed
+func blockedOnCI(p pr) bool {
+	switch p.checksState {
+	case "failure", "error", "pending", "expected":
+		return true
+	default:
+		return false
+	}
+}
+
+// blockedOnReview is true for PRs which still need an approval
+func blockedOnReview(p pr) bool {
+	return !fullyApproved(p)
+}
+
+// computeStats summarizes the visible chains
+func computeStats(d data, mappings map[int]mapping, opts FilterOptions) JSONStats {
+	stats := JSONStats{Authors: []JSONAuthorCount{}}
+	authors := map[string]int{}
+
+	// walk returns how many levels deep the deepest branch goes
+	var walk func(nodes []treeNode, depth int) int
+	walk = func(nodes []treeNode, depth int) int {
+		deepest := depth
+		for _, n := range nodes {
+			p := d.prs[n.number]
+			stats.PullRequests++
+			stats.Additions += p.additions
+			stats.Deletions += p.deletions
+			authors[p.author]++
+			if blockedOnCI(p) {
+				stats.BlockedOnCI++
+			}
+			if blockedOnReview(p) {
+				stats.BlockedOnReview++
+			}
+
+			deepest = max(deepest, walk(n.children, depth+1))
+		}
+		return deepest
+	}
+
+	totalDepth := 0
+	for _, n := range visibleTree(d, filterWholeChains(d, mappings, opts), 0, opts) {
+		stats.Chains++
+		totalDepth += walk([]treeNode{n}, 0)
+
+		root := d.prs[n.number]
+		if stats.OldestChain == nil || root.createdAt.Before(stats.OldestChain.CreatedAt) {
+			stats.OldestChain = &JSONOldestChain{
+				Number:    root.number,
+				Title:     root.title,
+				URL:       fmt.Sprintf("%s/pull/%d", d.url, root.number),
+				CreatedAt: root.createdAt,
+			}
+		}
+	}
+
+	if stats.Chains > 0 {
+		stats.AverageDepth = float64(totalDepth) / float64(stats.Chains)
+	}
+
+	for author, count := range authors {
+		stats.Authors = append(stats.Authors, JSONAuthorCount{Author: author, PullRequests: count})
+	}
+	slices.SortFunc(stats.Authors, func(a, b JSONAuthorCount) int {
+		if c := cmp.Compare(b.PullRequests, a.PullRequests); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Author, b.Author)
+	})
+
+	return stats
+}
+
+func printStats(d data, all bool, output string, opts FilterOptions) error {
+	mappings := d.mappings
+	if !all {
+		mappings = filterChains(d.mappings)
+	}
+
+	stats := computeStats(d, mappings, opts)
+
+	if output == "json" {
+		out, _ := json.MarshalIndent(stats, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	oldest := "-"
+	if stats.OldestChain != nil {
+		oldest = fmt.Sprintf("#%d %s (%s)",
+			stats.OldestChain.Number,
+			stats.OldestChain.Title,
+			formatAge(time.Since(stats.OldestChain.CreatedAt)))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Chains\t%d\n", stats.Chains)
+	fmt.Fprintf(w, "PRs\t%d\n", stats.PullRequests)
+	fmt.Fprintf(w, "Average depth\t%.1f\n", stats.AverageDepth)
+	fmt.Fprintf(w, "Oldest open stack\t%s\n", oldest)
+	fmt.Fprintf(w, "Lines under review\t%d (+%d -%d)\n", stats.Additions+stats.Deletions, stats.Additions, stats.Deletions)
+	fmt.Fprintf(w, "Blocked on CI\t%d\n", stats.BlockedOnCI)
+	fmt.Fprintf(w, "Blocked on reviews\t%d\n", stats.BlockedOnReview)
+
+	if len(stats.Authors) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Author\tPRs")
+		for _, a := range stats.Authors {
+			fmt.Fprintf(w, "%s\t%d\n", a.Author, a.PullRequests)
+		}
+	}
+
+	return w.Flush()
+}
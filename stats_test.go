@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComputeStats(t *testing.T) {
+	d := groupTestData()
+	d.prs[3] = pr{number: 3, author: "bob", createdAt: d.prs[3].createdAt, additions: 50, deletions: 5, checksState: "failure", approvers: []string{"alice"}}
+	d.prs[4] = pr{number: 4, author: "alice", createdAt: d.prs[4].createdAt, additions: 20, checksState: "pending", hasChangesRequested: true}
+
+	stats := computeStats(d, d.mappings, FilterOptions{})
+
+	if stats.Chains != 2 || stats.PullRequests != 4 {
+		t.Errorf("expected 2 chains of 4 PRs, got %d chains of %d PRs", stats.Chains, stats.PullRequests)
+	}
+	// #1 is two levels deep and #2 just one
+	if stats.AverageDepth != 1.5 {
+		t.Errorf("expected average depth 1.5, got %v", stats.AverageDepth)
+	}
+	if stats.OldestChain == nil || stats.OldestChain.Number != 2 {
+		t.Errorf("expected #2 to be the oldest chain, got %+v", stats.OldestChain)
+	}
+	if stats.Additions != 380 || stats.Deletions != 5 {
+		t.Errorf("expected +380 -5, got +%d -%d", stats.Additions, stats.Deletions)
+	}
+	if stats.BlockedOnCI != 2 || stats.BlockedOnReview != 2 {
+		t.Errorf("expected 2 PRs blocked on CI and 2 on reviews, got %d and %d", stats.BlockedOnCI, stats.BlockedOnReview)
+	}
+
+	want := []JSONAuthorCount{{"alice", 2}, {"bob", 2}}
+	if !slices.Equal(stats.Authors, want) {
+		t.Errorf("got authors %v, want %v", stats.Authors, want)
+	}
+
+	// Filters apply to every metric
+	stats = computeStats(d, d.mappings, FilterOptions{Author: "bob"})
+	if stats.Chains != 2 || stats.PullRequests != 2 || stats.AverageDepth != 1 {
+		t.Errorf("expected 2 single PR chains for bob, got %+v", stats)
+	}
+
+	// and chain filters keep or drop whole chains
+	stats = computeStats(d, d.mappings, FilterOptions{MinDepth: 2})
+	if stats.Chains != 1 || stats.PullRequests != 3 {
+		t.Errorf("expected the chain of 3 PRs, got %+v", stats)
+	}
+
+	stats = computeStats(d, d.mappings, FilterOptions{Author: "nobody"})
+	if stats.Chains != 0 || stats.OldestChain != nil || stats.AverageDepth != 0 {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/fatih/color"
+)
+
+// blockReasons describes the reasons a PR can't be merged, see
+// blockedBy for the order in which they are checked
+var blockReasons = map[string]string{
+	"draft":             "draft",
+	"conflicts":         "merge conflicts",
+	"checks":            "failing checks",
+	"changes-requested": "changes requested",
+	"review":            "missing approvals",
+	"pending-checks":    "pending checks",
+	"behind":            "out of date with base",
+	"protection":        "branch protection",
+}
+
+func blockReasonNames() []string {
+	names := []string{}
+	for name := range blockReasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// blockedBy combines the draft, merge, review and CI state of a PR into
+// the reason it can't be merged, or an empty string if it's ready
+func blockedBy(p pr) string {
+	switch {
+	case p.isDraft || p.mergeState == "draft":
+		return "draft"
+	case p.mergeable == "conflicting" || p.mergeState == "dirty":
+		return "conflicts"
+	case p.checksState == "failure" || p.checksState == "error":
+		return "checks"
+	case p.hasChangesRequested || p.reviewDecision == "changes_requested":
+		return "changes-requested"
+	case p.reviewDecision == "review_required",
+		// Without required reviews GitHub doesn't make a decision
+		len(p.reviewDecision) == 0 && !fullyApproved(p):
+		return "review"
+	case p.checksState == "pending" || p.checksState == "expected":
+		return "pending-checks"
+	case p.mergeState == "behind":
+		return "behind"
+	case p.mergeState == "blocked":
+		return "protection"
+	default:
+		return ""
+	}
+}
+
+// prStatus is "ready" for PRs which can be merged and "blocked" otherwise
+func prStatus(p pr) string {
+	if len(blockedBy(p)) > 0 {
+		return "blocked"
+	}
+	return "ready"
+}
+
+// chainBlockedBy finds the first PR holding up a chain, parents before
+// their children. It returns nil if the whole chain is ready.
+func chainBlockedBy(p pr, children []JSONChain) *JSONBlocker {
+	if reason := blockedBy(p); len(reason) > 0 {
+		return &JSONBlocker{Number: p.number, Reason: reason}
+	}
+
+	for _, c := range children {
+		if c.BlockedBy != nil {
+			return c.BlockedBy
+		}
+	}
+
+	return nil
+}
+
+// landingBlockedBy finds the first PR holding up a PR from being
+// merged, checking its parents from the root down before the PR
+// itself. It returns nil if nothing is in the way.
+func landingBlockedBy(d data, number int) *JSONBlocker {
+	stack := []int{}
+	for n := number; n != 0 && !slices.Contains(stack, n); n = d.mappings[n].base {
+		stack = append([]int{n}, stack...)
+	}
+
+	for _, n := range stack {
+		if reason := blockedBy(d.prs[n]); len(reason) > 0 {
+			return &JSONBlocker{Number: n, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// stackMappings narrows down the mappings to the given PRs, which are
+// the parents and children of one PR as returned by filterByNumber
+func stackMappings(d data, prns []int) map[int]mapping {
+	mappings := map[int]mapping{0: {following: []int{}}}
+	for _, n := range prns {
+		if n == 0 {
+			continue
+		}
+
+		base := d.mappings[n].base
+		m := mappings[base]
+		m.following = append(m.following, n)
+		mappings[base] = m
+
+		if _, ok := mappings[n]; !ok {
+			mappings[n] = mapping{base: base, following: []int{}}
+		} else {
+			m := mappings[n]
+			m.base = base
+			mappings[n] = m
+		}
+	}
+
+	return mappings
+}
+
+// statusSummary describes whether a PR can be merged
+func statusSummary(d data, number int) string {
+	blocker := landingBlockedBy(d, number)
+	switch {
+	case blocker == nil:
+		return color.New(color.FgGreen).Sprint("Ready to merge")
+	case blocker.Number == number:
+		return color.New(color.FgRed).Sprintf("Blocked by %s", blockReasons[blocker.Reason])
+	default:
+		return color.New(color.FgYellow).Sprintf("Waiting on #%d, blocked by %s", blocker.Number, blockReasons[blocker.Reason])
+	}
+}
+
+// printStatus prints a PR along with its parents and children
+func printStatus(d data, filter string, output string) error {
+	number, err := strconv.Atoi(filter)
+	if err != nil {
+		number = d.branch[filter]
+	}
+	if _, ok := d.prs[number]; !ok {
+		return fmt.Errorf("no PR found for %s", filter)
+	}
+
+	mappings := stackMappings(d, filterByNumber(d, number))
+	root := mappings[0].following[0]
+
+	if output == "json" {
+		status := JSONStatusOutput{
+			PullRequest: toJSONPullRequest(d.prs[number], d.url),
+			Status:      "ready",
+			BlockedBy:   landingBlockedBy(d, number),
+			Chain:       buildJSONChain(d, mappings, root, FilterOptions{}),
+		}
+		if status.BlockedBy != nil {
+			status.Status = "blocked"
+		}
+
+		outputBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outputBytes))
+		return nil
+	}
+
+	p := d.prs[number]
+	fmt.Printf("#%d %s [%s]\n", p.number, p.title, p.head)
+	fmt.Println(statusSummary(d, number))
+	fmt.Println()
+
+	nodes := visibleTree(d, mappings, 0, FilterOptions{})
+	lines, _ := renderTree(nodes, "", true, treeStyleForTerm(os.Getenv("TERM")), func(n int) (string, error) {
+		line := formatPR(d.prs[n], d.url)
+		if n == number {
+			line += " " + color.New(color.Bold).Sprint("◀")
+		}
+		return line, nil
+	})
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	return nil
+}
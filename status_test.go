@@ -0,0 +1,133 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBlockedBy(t *testing.T) {
+	approved := []string{"bob"}
+
+	tests := []struct {
+		name string
+		pr   pr
+		want string
+	}{
+		{"ready", pr{approvers: approved, checksState: "success", mergeState: "clean", reviewDecision: "approved"}, ""},
+		{"ready without protection", pr{approvers: approved, checksState: "success"}, ""},
+		{"draft", pr{isDraft: true, mergeable: "conflicting"}, "draft"},
+		{"conflicts", pr{approvers: approved, mergeable: "conflicting"}, "conflicts"},
+		{"dirty", pr{approvers: approved, mergeState: "dirty"}, "conflicts"},
+		{"failing checks before reviews", pr{checksState: "failure"}, "checks"},
+		{"changes requested", pr{approvers: approved, hasChangesRequested: true}, "changes-requested"},
+		{"review required", pr{approvers: approved, reviewDecision: "review_required"}, "review"},
+		{"not enough approvals", pr{approvers: approved, requiredApprovals: 2}, "review"},
+		{"github decision wins", pr{reviewDecision: "approved"}, ""},
+		{"pending checks", pr{approvers: approved, checksState: "pending"}, "pending-checks"},
+		{"behind", pr{approvers: approved, mergeState: "behind"}, "behind"},
+		{"other protection rules", pr{approvers: approved, reviewDecision: "approved", mergeState: "blocked"}, "protection"},
+		{"unstable isn't blocking", pr{approvers: approved, mergeState: "unstable"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := blockedBy(tt.pr)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if _, ok := blockReasons[got]; got != "" && !ok {
+				t.Errorf("reason %q has no description", got)
+			}
+		})
+	}
+}
+
+func TestBuildJSONChain_Status(t *testing.T) {
+	ready := pr{approvers: []string{"bob"}, checksState: "success"}
+	one, two, three := ready, ready, ready
+	one.number, two.number, three.number = 1, 2, 3
+	two.checksState = "failure"
+	three.isDraft = true
+
+	d := makeTestData(
+		map[int]pr{1: one, 2: two, 3: three},
+		map[int]mapping{
+			0: {following: []int{1}},
+			1: {base: 0, following: []int{2}},
+			2: {base: 1, following: []int{3}},
+		},
+	)
+
+	chain := buildJSONChain(d, d.mappings, 1, FilterOptions{})
+	if chain.Status != "blocked" || chain.BlockedBy == nil || *chain.BlockedBy != (JSONBlocker{Number: 2, Reason: "checks"}) {
+		t.Errorf("expected chain blocked by #2, got %s %+v", chain.Status, chain.BlockedBy)
+	}
+	if chain.PullRequest.Status != "ready" || chain.PullRequest.BlockedBy != "" {
+		t.Errorf("expected #1 itself to be ready, got %+v", chain.PullRequest)
+	}
+
+	sub := chain.Children[0].Children[0]
+	if sub.Status != "blocked" || sub.BlockedBy.Number != 3 || sub.PullRequest.BlockedBy != "draft" {
+		t.Errorf("expected #3 blocked as a draft, got %+v", sub)
+	}
+
+	d.prs[2], d.prs[3] = ready, ready
+	chain = buildJSONChain(d, d.mappings, 1, FilterOptions{})
+	if chain.Status != "ready" || chain.BlockedBy != nil {
+		t.Errorf("expected ready chain, got %s %+v", chain.Status, chain.BlockedBy)
+	}
+}
+
+func TestLandingBlockedBy(t *testing.T) {
+	// 0 -> 1 -> 2 -> 3
+	//        -> 4
+	ready := pr{approvers: []string{"bob"}, checksState: "success"}
+	withNumber := func(p pr, n int) pr {
+		p.number = n
+		return p
+	}
+	failing := ready
+	failing.checksState = "failure"
+
+	d := makeTestData(
+		map[int]pr{
+			1: withNumber(ready, 1),
+			2: withNumber(failing, 2),
+			3: withNumber(pr{}, 3),
+			4: withNumber(ready, 4),
+		},
+		map[int]mapping{
+			0: {following: []int{1}},
+			1: {base: 0, following: []int{2, 4}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+			4: {base: 1, following: []int{}},
+		},
+	)
+
+	tests := []struct {
+		number int
+		want   *JSONBlocker
+	}{
+		{1, nil},
+		{2, &JSONBlocker{Number: 2, Reason: "checks"}},
+		{3, &JSONBlocker{Number: 2, Reason: "checks"}},
+		{4, nil},
+	}
+
+	for _, tt := range tests {
+		got := landingBlockedBy(d, tt.number)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("landingBlockedBy(%d) = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+
+	// Siblings aren't part of the stack
+	m := stackMappings(d, filterByNumber(d, 2))
+	if _, ok := m[4]; ok {
+		t.Errorf("expected sibling #4 to be left out, got %v", m)
+	}
+	if !slices.Equal(m[0].following, []int{1}) || !slices.Equal(m[1].following, []int{2}) || !slices.Equal(m[2].following, []int{3}) {
+		t.Errorf("unexpected stack %v", m)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// templatePR is the data available to --format templates. It contains
+// every field of the JSON output along with where the PR is displayed.
+type templatePR struct {
+	JSONPullRequest
+
+	Depth       int // nesting level in the output, 0 for roots
+	Root        int // number of the first PR in the chain
+	Position    int // 1-based position within the chain
+	ChainLength int // number of PRs displayed in the chain
+}
+
+var templateColors = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"bold":    color.Bold,
+	"faint":   color.Faint,
+}
+
+var templateFuncs = template.FuncMap{
+	// color colors text with a named color, or with the color derived
+	// from the text itself when the name is "hash" (as for authors)
+	"color": func(name, text string) (string, error) {
+		if name == "hash" {
+			return generateColor(text).Sprint(text), nil
+		}
+
+		attr, ok := templateColors[name]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", name)
+		}
+
+		return color.New(attr).Sprint(text), nil
+	},
+	"age": func(t time.Time) string {
+		return formatAge(time.Since(t))
+	},
+	"hyperlink": hyperlink,
+	"truncate": func(n int, s string) string {
+		r := []rune(s)
+		if len(r) <= n {
+			return s
+		}
+		if n < 1 {
+			return ""
+		}
+		return string(r[:n-1]) + "…"
+	},
+	"ci": ciIndicator,
+}
+
+// parseFormat parses a --format template. The format is either the
+// name of a template from the config file or the template itself.
+func parseFormat(format string, named map[string]string) (*template.Template, error) {
+	if len(format) == 0 {
+		return nil, nil
+	}
+
+	if t, ok := named[format]; ok {
+		format = t
+	}
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+func executeFormat(tmpl *template.Template, p templatePR) (string, error) {
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, p)
+	if err != nil {
+		return "", fmt.Errorf("unable to format #%d: %v", p.Number, err)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestParseFormat(t *testing.T) {
+	named := map[string]string{"short": "#{{.Number}}"}
+
+	tmpl, err := parseFormat("", named)
+	if err != nil || tmpl != nil {
+		t.Errorf("expected no template for empty format, got %v, %v", tmpl, err)
+	}
+
+	tmpl, err = parseFormat("short", named)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := executeFormat(tmpl, templatePR{JSONPullRequest: JSONPullRequest{Number: 4}})
+	if got != "#4" {
+		t.Errorf("expected named template to be used, got %q", got)
+	}
+
+	_, err = parseFormat("{{.Number", named)
+	if err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestExecuteFormat(t *testing.T) {
+	color.NoColor = true
+
+	p := templatePR{
+		JSONPullRequest: JSONPullRequest{
+			Number:      7,
+			Title:       "A rather long title for a PR",
+			Author:      "alice",
+			ChecksState: "success",
+			CreatedAt:   time.Now().Add(-49 * time.Hour),
+			URL:         "https://github.com/test/repo/pull/7",
+		},
+		Depth:       1,
+		Root:        5,
+		Position:    2,
+		ChainLength: 3,
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"fields", "{{.Number}} {{.Author}}", "7 alice"},
+		{"position", "{{.Position}}/{{.ChainLength}} of #{{.Root}} at {{.Depth}}", "2/3 of #5 at 1"},
+		{"truncate", "{{truncate 10 .Title}}", "A rather …"},
+		{"truncate short", "{{truncate 40 .Author}}", "alice"},
+		{"age", "{{age .CreatedAt}}", "2d"},
+		{"color", `{{color "green" .Author}}`, "alice"},
+		{"hash color", `{{color "hash" .Author}}`, "alice"},
+		{"ci", "{{ci .ChecksState}}", "✓"},
+		{"hyperlink", `{{hyperlink .URL "x"}}`, "\x1b]8;;https://github.com/test/repo/pull/7\x07x\x1b]8;;\x07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseFormat(tt.format, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := executeFormat(tmpl, p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	tmpl, _ := parseFormat(`{{color "pink" .Author}}`, nil)
+	_, err := executeFormat(tmpl, p)
+	if err == nil || !strings.Contains(err.Error(), "unknown color") {
+		t.Errorf("expected unknown color error, got %v", err)
+	}
+}
+
+func TestChainPositions(t *testing.T) {
+	nodes := []treeNode{
+		{number: 1, children: []treeNode{
+			{number: 2, children: []treeNode{{number: 4}}},
+			{number: 3},
+		}},
+		{number: 5},
+	}
+
+	got := chainPositions(nodes)
+	want := map[int]prPosition{
+		1: {depth: 0, root: 1, index: 1, length: 4},
+		2: {depth: 1, root: 1, index: 2, length: 4},
+		4: {depth: 2, root: 1, index: 3, length: 4},
+		3: {depth: 1, root: 1, index: 4, length: 4},
+		5: {depth: 0, root: 5, index: 1, length: 1},
+	}
+
+	for n, w := range want {
+		if got[n] != w {
+			t.Errorf("#%d: got %+v, want %+v", n, got[n], w)
+		}
+	}
+}
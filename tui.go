@@ -0,0 +1,498 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const tuiHelp = "j/k move  enter fold  / filter  a all  o open  y copy  c checkout  r rebase  q quit"
+
+// tuiAction is something the TUI has to do outside of its own state
+type tuiAction int
+
+const (
+	tuiNone tuiAction = iota
+	tuiQuit
+	tuiOpen
+	tuiCopy
+	tuiCheckout
+	tuiRebase
+)
+
+// tuiRow is a line in the chain list
+type tuiRow struct {
+	number      int
+	depth       int
+	hasChildren bool
+}
+
+// tui holds the state of the interactive chain browser. It does no IO
+// itself so that it can be driven by tests; see runTUI for that.
+type tui struct {
+	d         data
+	opts      FilterOptions
+	all       bool
+	collapsed map[int]bool
+
+	rows   []tuiRow
+	cursor int
+	offset int
+
+	editing    bool
+	filterText string
+	prevFilter string
+	status     string
+
+	browser string // command to open URLs with, see openBrowser
+}
+
+func newTUI(d data, filter string) (*tui, error) {
+	opts, err := parseTUIFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tui{
+		d:          d,
+		opts:       opts.compile(),
+		collapsed:  map[int]bool{},
+		filterText: filter,
+	}
+	t.refresh()
+
+	return t, nil
+}
+
+// refresh rebuilds the rows after the filters or folds changed
+func (t *tui) refresh() {
+	selected := t.selected()
+
+	mappings := t.d.mappings
+	if !t.all {
+		mappings = filterChains(t.d.mappings)
+	}
+	mappings = filterWholeChains(t.d, mappings, t.opts)
+
+	t.rows = []tuiRow{}
+	var walk func(nodes []treeNode, depth int)
+	walk = func(nodes []treeNode, depth int) {
+		for _, n := range nodes {
+			t.rows = append(t.rows, tuiRow{number: n.number, depth: depth, hasChildren: len(n.children) > 0})
+			if !t.collapsed[n.number] {
+				walk(n.children, depth+1)
+			}
+		}
+	}
+	walk(visibleTree(t.d, mappings, 0, t.opts), 0)
+
+	// Keep the same PR selected if it is still visible
+	t.cursor = min(t.cursor, max(len(t.rows)-1, 0))
+	for i, r := range t.rows {
+		if r.number == selected {
+			t.cursor = i
+		}
+	}
+}
+
+// selected returns the number of the selected PR, 0 if there is none
+func (t *tui) selected() int {
+	if t.cursor < 0 || t.cursor >= len(t.rows) {
+		return 0
+	}
+	return t.rows[t.cursor].number
+}
+
+func (t *tui) handleKey(key string) tuiAction {
+	if t.editing {
+		t.handleFilterKey(key)
+		return tuiNone
+	}
+
+	t.status = ""
+	switch key {
+	case "q", "ctrl-c":
+		return tuiQuit
+	case "j", "down":
+		t.cursor = min(t.cursor+1, max(len(t.rows)-1, 0))
+	case "k", "up":
+		t.cursor = max(t.cursor-1, 0)
+	case "g":
+		t.cursor = 0
+	case "G":
+		t.cursor = max(len(t.rows)-1, 0)
+	case "enter", " ":
+		if n := t.selected(); n != 0 {
+			t.collapsed[n] = !t.collapsed[n]
+			t.refresh()
+		}
+	case "h", "left":
+		if n := t.selected(); n != 0 {
+			t.collapsed[n] = true
+			t.refresh()
+		}
+	case "l", "right":
+		if n := t.selected(); n != 0 {
+			delete(t.collapsed, n)
+			t.refresh()
+		}
+	case "a":
+		t.all = !t.all
+		t.refresh()
+	case "/":
+		t.editing = true
+		t.prevFilter = t.filterText
+	case "o":
+		return t.actionOnSelected(tuiOpen)
+	case "y":
+		return t.actionOnSelected(tuiCopy)
+	case "c":
+		return t.actionOnSelected(tuiCheckout)
+	case "r":
+		return t.actionOnSelected(tuiRebase)
+	}
+
+	return tuiNone
+}
+
+func (t *tui) actionOnSelected(action tuiAction) tuiAction {
+	if t.selected() == 0 {
+		t.status = "No PR selected"
+		return tuiNone
+	}
+	return action
+}
+
+// handleFilterKey edits the filter, applying it as it is typed
+func (t *tui) handleFilterKey(key string) {
+	switch key {
+	case "enter":
+		t.editing = false
+	case "esc", "ctrl-c":
+		t.editing = false
+		t.filterText = t.prevFilter
+	case "backspace":
+		r := []rune(t.filterText)
+		if len(r) > 0 {
+			t.filterText = string(r[:len(r)-1])
+		}
+	default:
+		if len(key) == 0 || strings.ContainsFunc(key, func(r rune) bool { return r < ' ' }) {
+			return
+		}
+		t.filterText += key
+	}
+
+	opts, err := parseTUIFilter(t.filterText)
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+
+	t.status = ""
+	t.opts = opts.compile()
+	t.refresh()
+}
+
+// render draws the screen: the chain list on the left, details of the
+// selected PR on the right and a status line at the bottom.
+func (t *tui) render(width, height int) []string {
+	listHeight := max(height-2, 1)
+	listWidth := width * 3 / 5
+	detailWidth := max(width-listWidth-3, 0)
+
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+listHeight {
+		t.offset = t.cursor - listHeight + 1
+	}
+
+	details := t.details()
+	lines := make([]string, 0, height)
+	for i := 0; i < listHeight; i++ {
+		left := ""
+		if idx := t.offset + i; idx < len(t.rows) {
+			left = t.renderRow(t.rows[idx], listWidth, idx == t.cursor)
+		} else {
+			left = strings.Repeat(" ", listWidth)
+		}
+
+		right := ""
+		if i < len(details) {
+			right = fit(details[i], detailWidth)
+		}
+
+		lines = append(lines, left+" │ "+right)
+	}
+
+	filter := t.filterText
+	if len(filter) == 0 {
+		filter = "(none)"
+	}
+	lines = append(lines, fit(fmt.Sprintf("%d PRs  filter: %s", len(t.rows), filter), width))
+
+	switch {
+	case t.editing:
+		lines = append(lines, fit("filter: "+t.filterText+"▏  "+t.status, width))
+	case len(t.status) > 0:
+		lines = append(lines, fit(t.status, width))
+	default:
+		lines = append(lines, fit(tuiHelp, width))
+	}
+
+	return lines
+}
+
+func (t *tui) renderRow(r tuiRow, width int, selected bool) string {
+	p := t.d.prs[r.number]
+
+	marker := "  "
+	if r.hasChildren {
+		marker = "▾ "
+		if t.collapsed[r.number] {
+			marker = "▸ "
+		}
+	}
+
+	number := fmt.Sprintf("#%d", p.number)
+	ci := map[string]string{"success": " ✓", "failure": " ✗", "error": " ✗", "pending": " ●"}[p.checksState]
+	prefix := strings.Repeat("  ", r.depth) + marker + number + " "
+	suffix := fmt.Sprintf(" (%s)%s", p.author, ci)
+
+	title := fit(p.title, max(width-runeLen(prefix)-runeLen(suffix), 0))
+	line := fit(prefix+strings.TrimRight(title, " ")+suffix, width)
+
+	// Colors are applied after fitting so that escape codes don't
+	// count towards the width.
+	if fullyApproved(p) {
+		line = strings.Replace(line, number, "\x1b[32m"+number+"\x1b[39m", 1)
+	}
+	if selected {
+		line = "\x1b[7m" + line + "\x1b[27m"
+	}
+
+	return line
+}
+
+func (t *tui) details() []string {
+	n := t.selected()
+	if n == 0 {
+		return []string{"No PRs match the filter"}
+	}
+
+	p := t.d.prs[n]
+	yesNo := map[bool]string{true: "yes", false: "no"}
+
+	review := "pending"
+	switch {
+	case p.hasChangesRequested:
+		review = "changes requested"
+	case len(p.approvers) > 0:
+		review = "approved by " + strings.Join(p.approvers, ", ")
+		if p.requiredApprovals > 0 {
+			review += fmt.Sprintf(" (%d/%d)", len(p.approvers), p.requiredApprovals)
+		}
+	}
+	if p.hasComments {
+		review += " (commented)"
+	}
+
+	checks := p.checksState
+	if len(checks) == 0 {
+		checks = "none"
+	}
+
+	status := "ready to merge"
+	if reason := blockedBy(p); len(reason) > 0 {
+		status = "blocked by " + blockReasons[reason]
+	}
+
+	size := "small"
+	switch changes := p.additions + p.deletions; {
+	case changes > 500:
+		size = "large"
+	case changes > 100:
+		size = "medium"
+	}
+
+	return []string{
+		fmt.Sprintf("#%d %s", p.number, p.title),
+		"",
+		"Author:    " + p.author,
+		"Branch:    " + p.head + " → " + p.base,
+		"Review:    " + review,
+		"Reviewers: " + strings.Join(p.reviewers, ", "),
+		"Checks:    " + checks,
+		"Mergeable: " + p.mergeable,
+		"Status:    " + status,
+		"Draft:     " + yesNo[p.isDraft],
+		"Labels:    " + strings.Join(p.labels, ", "),
+		fmt.Sprintf("Size:      +%d -%d (%s)", p.additions, p.deletions, size),
+		fmt.Sprintf("Created:   %s (%s ago)", p.createdAt.Format("2006-01-02"), formatAge(time.Since(p.createdAt))),
+		fmt.Sprintf("Updated:   %s (%s ago)", p.updatedAt.Format("2006-01-02"), formatAge(time.Since(p.updatedAt))),
+		"",
+		fmt.Sprintf("%s/pull/%d", t.d.url, p.number),
+	}
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
+
+// fit truncates or pads s to exactly width runes
+func fit(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		if width == 0 {
+			return ""
+		}
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// parseKey turns raw terminal input into a key name
+func parseKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A", "\x1bOA":
+		return "up"
+	case "\x1b[B", "\x1bOB":
+		return "down"
+	case "\x1b[C", "\x1bOC":
+		return "right"
+	case "\x1b[D", "\x1bOD":
+		return "left"
+	case "\x1b":
+		return "esc"
+	case "\r", "\n":
+		return "enter"
+	case "\x7f", "\x08":
+		return "backspace"
+	case "\x03":
+		return "ctrl-c"
+	}
+
+	return string(b)
+}
+
+// runTUI runs the interactive chain browser on the terminal
+func runTUI(d data, filter string, browser string) error {
+	t, err := newTUI(d, filter)
+	if err != nil {
+		return err
+	}
+	t.browser = browser
+
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return fmt.Errorf("tui needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+
+	// Alternate screen and hidden cursor while running
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			fmt.Print("\x1b[?25h\x1b[?1049l")
+			term.Restore(in, state)
+		})
+	}
+	defer restore()
+
+	keys := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- slices.Clone(buf[:n])
+		}
+	}()
+
+	// Terminal size is polled as there is no portable resize signal
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	width, height := 0, 0
+	draw := func() {
+		width, height, _ = term.GetSize(out)
+		var sb strings.Builder
+		for i, line := range t.render(width, height) {
+			fmt.Fprintf(&sb, "\x1b[%d;1H%s\x1b[K", i+1, line)
+		}
+		fmt.Print(sb.String())
+	}
+	draw()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w, h, _ := term.GetSize(out); w != width || h != height {
+				draw()
+			}
+			continue
+		case b, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			p := t.d.prs[t.selected()]
+			url := fmt.Sprintf("%s/pull/%d", t.d.url, p.number)
+
+			switch t.handleKey(parseKey(b)) {
+			case tuiQuit:
+				return nil
+			case tuiOpen:
+				err := openBrowser([]string{url}, t.browser)
+				if errors.Is(err, errNoBrowser) {
+					fmt.Print(copyToClipboard(url))
+					t.status = "No browser available, copied " + url
+				} else if err != nil {
+					t.status = fmt.Sprintf("Unable to open %s: %v", url, err)
+				} else {
+					t.status = "Opened " + url
+				}
+				// openBrowser prints to the screen
+				fmt.Print("\x1b[2J")
+			case tuiCopy:
+				// OSC 52 asks the terminal to set the clipboard
+				fmt.Print(copyToClipboard(url))
+				t.status = "Copied " + url
+			case tuiCheckout:
+				out, err := exec.Command("git", "checkout", p.head).CombinedOutput()
+				if err != nil {
+					t.status = fmt.Sprintf("git checkout %s: %s", p.head, strings.TrimSpace(string(out)))
+				} else {
+					t.status = "Checked out " + p.head
+				}
+			case tuiRebase:
+				prns := filterChain(t.d, strconv.Itoa(p.number))
+				script, _ := rebaseScript(t.d, prns, false, "")
+
+				// Leave the TUI so the script can be copied or piped
+				restore()
+				fmt.Println(script)
+				return nil
+			}
+
+			draw()
+		}
+	}
+}
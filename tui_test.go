@@ -0,0 +1,188 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func tuiTestData() data {
+	// 0 -> 1 -> 2 -> 3
+	// 0 -> 4 -> 5
+	// 0 -> 6
+	return makeTestData(
+		map[int]pr{
+			1: {number: 1, title: "One", author: "alice", head: "one", base: "main", approvers: []string{"bob"}},
+			2: {number: 2, title: "Two", author: "bob", head: "two", base: "one", checksState: "failure"},
+			3: {number: 3, title: "Three", author: "alice", head: "three", base: "two"},
+			4: {number: 4, title: "Four", author: "carol", head: "four", base: "main"},
+			5: {number: 5, title: "Five", author: "carol", head: "five", base: "four"},
+			6: {number: 6, title: "Six", author: "dave", head: "six", base: "main"},
+		},
+		map[int]mapping{
+			0: {following: []int{1, 4, 6}},
+			1: {base: 0, following: []int{2}},
+			2: {base: 1, following: []int{3}},
+			3: {base: 2, following: []int{}},
+			4: {base: 0, following: []int{5}},
+			5: {base: 4, following: []int{}},
+			6: {base: 0, following: []int{}},
+		},
+	)
+}
+
+func tuiRowNumbers(t *tui) []int {
+	nums := []int{}
+	for _, r := range t.rows {
+		nums = append(nums, r.number)
+	}
+	return nums
+}
+
+func TestTUI_NavigateAndFold(t *testing.T) {
+	ui, err := newTUI(tuiTestData(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected rows %v", got)
+	}
+
+	ui.handleKey("j")
+	if ui.selected() != 2 {
+		t.Errorf("expected #2 to be selected, got #%d", ui.selected())
+	}
+
+	// Folding #2 hides #3
+	ui.handleKey("enter")
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{1, 2, 4, 5}) {
+		t.Errorf("unexpected rows after fold %v", got)
+	}
+	ui.handleKey("l")
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("unexpected rows after unfold %v", got)
+	}
+
+	// Standalone PRs are shown with a
+	ui.handleKey("a")
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("unexpected rows with all %v", got)
+	}
+	if ui.selected() != 2 {
+		t.Errorf("expected selection to be kept, got #%d", ui.selected())
+	}
+
+	ui.handleKey("G")
+	if ui.selected() != 6 {
+		t.Errorf("expected last PR to be selected, got #%d", ui.selected())
+	}
+	ui.handleKey("down")
+	if ui.selected() != 6 {
+		t.Errorf("expected selection to stay at the end, got #%d", ui.selected())
+	}
+
+	if ui.handleKey("r") != tuiRebase || ui.handleKey("o") != tuiOpen || ui.handleKey("q") != tuiQuit {
+		t.Error("unexpected actions")
+	}
+}
+
+func TestTUI_LiveFilter(t *testing.T) {
+	ui, err := newTUI(tuiTestData(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ui.handleKey("/")
+	for _, k := range strings.Split("author=carol", "") {
+		ui.handleKey(k)
+	}
+
+	// Filters are applied while typing
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{4, 5}) {
+		t.Errorf("unexpected rows %v", got)
+	}
+	if ui.opts.Author != "carol" {
+		t.Errorf("unexpected filter %+v", ui.opts)
+	}
+
+	// Escape goes back to the previous filter
+	ui.handleKey("esc")
+	if ui.editing || ui.filterText != "" {
+		t.Errorf("expected filter to be reverted, got %q", ui.filterText)
+	}
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("unexpected rows %v", got)
+	}
+
+	ui.handleKey("/")
+	for _, k := range []string{"c", "h", "e", "c", "k", "s", "=", "x"} {
+		ui.handleKey(k)
+	}
+	if !strings.Contains(ui.status, "invalid checks") {
+		t.Errorf("expected error in status, got %q", ui.status)
+	}
+	ui.handleKey("backspace")
+	for _, k := range strings.Split("fail", "") {
+		ui.handleKey(k)
+	}
+	ui.handleKey("enter")
+	if ui.editing || ui.opts.Checks != "fail" {
+		t.Errorf("expected checks filter, got %+v", ui.opts)
+	}
+	if got := tuiRowNumbers(ui); !slices.Equal(got, []int{2}) {
+		t.Errorf("unexpected rows %v", got)
+	}
+}
+
+func TestTUI_Render(t *testing.T) {
+	ui, err := newTUI(tuiTestData(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := ui.render(80, 6)
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d", len(lines))
+	}
+
+	// The list takes 3/5 of the width, details the rest
+	want := []string{
+		"\x1b[7m▾ \x1b[32m#1\x1b[39m One (alice)" + strings.Repeat(" ", 32) + "\x1b[27m │ " + fit("#1 One", 29),
+		fit("  ▾ #2 Two (bob) ✗", 48) + " │ " + fit("", 29),
+		fit("      #3 Three (alice)", 48) + " │ " + fit("Author:    alice", 29),
+		fit("▾ #4 Four (carol)", 48) + " │ " + fit("Branch:    one → main", 29),
+		fit("5 PRs  filter: (none)", 80),
+		tuiHelp[:79] + "…",
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d:\ngot  %q\nwant %q", i, lines[i], want[i])
+		}
+	}
+
+	// The list scrolls to keep the cursor visible
+	ui.handleKey("G")
+	lines = ui.render(80, 6)
+	if !strings.Contains(lines[3], "#5 Five") {
+		t.Errorf("expected #5 on the last list line, got %q", lines[3])
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]string{
+		"\x1b[A": "up",
+		"\x1bOB": "down",
+		"\x1b":   "esc",
+		"\r":     "enter",
+		"\x7f":   "backspace",
+		"\x03":   "ctrl-c",
+		"j":      "j",
+	}
+
+	for in, want := range tests {
+		if got := parseKey([]byte(in)); got != want {
+			t.Errorf("parseKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
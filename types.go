@@ -7,7 +7,8 @@ type Response struct {
 		Repository struct {
 			URL              string `json:"url"`
 			DefaultBranchRef struct {
-				Name string `json:"name"`
+				Name                 string                `json:"name"`
+				BranchProtectionRule *BranchProtectionRule `json:"branchProtectionRule"`
 			} `json:"defaultBranchRef"`
 			PullRequests struct {
 				Edges []struct {
@@ -19,7 +20,16 @@ type Response struct {
 						} `json:"author"`
 						HeadRefName string `json:"headRefName"`
 						BaseRefName string `json:"baseRefName"`
-						Reviews     struct {
+						BaseRef     *struct {
+							BranchProtectionRule   *BranchProtectionRule `json:"branchProtectionRule"`
+							AssociatedPullRequests struct {
+								Nodes []struct {
+									Number int    `json:"number"`
+									State  string `json:"state"`
+								} `json:"nodes"`
+							} `json:"associatedPullRequests"`
+						} `json:"baseRef"`
+						Reviews struct {
 							Edges []struct {
 								Node struct {
 									State  string `json:"state"`
@@ -29,16 +39,26 @@ type Response struct {
 								} `json:"node"`
 							} `json:"edges"`
 						} `json:"reviews"`
+						LatestOpinionatedReviews struct {
+							Nodes []struct {
+								State  string `json:"state"`
+								Author struct {
+									Login string `json:"login"`
+								} `json:"author"`
+							} `json:"nodes"`
+						} `json:"latestOpinionatedReviews"`
 						Labels struct {
 							Nodes []struct {
 								Name string `json:"name"`
 							} `json:"nodes"`
 						} `json:"labels"`
-						IsDraft   bool   `json:"isDraft"`
-						CreatedAt string `json:"createdAt"`
-						UpdatedAt string `json:"updatedAt"`
-						Mergeable string `json:"mergeable"`
-						Commits   struct {
+						IsDraft          bool   `json:"isDraft"`
+						CreatedAt        string `json:"createdAt"`
+						UpdatedAt        string `json:"updatedAt"`
+						Mergeable        string `json:"mergeable"`
+						MergeStateStatus string `json:"mergeStateStatus"`
+						ReviewDecision   string `json:"reviewDecision"`
+						Commits          struct {
 							Nodes []struct {
 								Commit struct {
 									StatusCheckRollup *struct {
@@ -72,6 +92,11 @@ type Response struct {
 	} `json:"errors"`
 }
 
+type BranchProtectionRule struct {
+	RequiresApprovingReviews     bool `json:"requiresApprovingReviews"`
+	RequiredApprovingReviewCount int  `json:"requiredApprovingReviewCount"`
+}
+
 type JSONPullRequest struct {
 	Number              int       `json:"number"`
 	Base                string    `json:"base"`
@@ -79,6 +104,8 @@ type JSONPullRequest struct {
 	Title               string    `json:"title"`
 	Author              string    `json:"author"`
 	ApprovedBy          string    `json:"approvedBy"`
+	Approvers           []string  `json:"approvers"`
+	RequiredApprovals   int       `json:"requiredApprovals"`
 	HasChangesRequested bool      `json:"hasChangesRequested"`
 	HasComments         bool      `json:"hasComments"`
 	Labels              []string  `json:"labels"`
@@ -86,6 +113,10 @@ type JSONPullRequest struct {
 	CreatedAt           time.Time `json:"createdAt"`
 	UpdatedAt           time.Time `json:"updatedAt"`
 	Mergeable           string    `json:"mergeable"`
+	MergeState          string    `json:"mergeState"`
+	ReviewDecision      string    `json:"reviewDecision"`
+	Status              string    `json:"status"`
+	BlockedBy           string    `json:"blockedBy,omitempty"`
 	ChecksState         string    `json:"checksState"`
 	Reviewers           []string  `json:"reviewers"`
 	Additions           int       `json:"additions"`
@@ -95,14 +126,89 @@ type JSONPullRequest struct {
 
 type JSONChain struct {
 	PullRequest JSONPullRequest `json:"pullRequest"`
+	Status      string          `json:"status"`              // ready if the whole chain can be merged
+	BlockedBy   *JSONBlocker    `json:"blockedBy,omitempty"` // first PR holding up the chain
 	Children    []JSONChain     `json:"children"`
 }
 
+type JSONBlocker struct {
+	Number int    `json:"number"`
+	Reason string `json:"reason"`
+}
+
+// JSONStatusOutput is the status of a PR and the chain it is part of
+type JSONStatusOutput struct {
+	PullRequest JSONPullRequest `json:"pullRequest"`
+	Status      string          `json:"status"`              // ready if the PR and all its parents can be merged
+	BlockedBy   *JSONBlocker    `json:"blockedBy,omitempty"` // first PR holding up this one, parents first
+	Chain       JSONChain       `json:"chain"`
+}
+
+type JSONProblem struct {
+	Kind    string `json:"kind"`
+	PRs     []int  `json:"prs"`
+	Message string `json:"message"`
+}
+
 type JSONOutput struct {
+	Chains   []JSONChain   `json:"chains"`
+	Problems []JSONProblem `json:"problems,omitempty"`
+}
+
+type JSONGroup struct {
+	Name   string      `json:"name"`
 	Chains []JSONChain `json:"chains"`
 }
 
+type JSONGroupedOutput struct {
+	Groups   []JSONGroup   `json:"groups"`
+	Problems []JSONProblem `json:"problems,omitempty"`
+}
+
 type JSONRebaseOutput struct {
 	Script   string   `json:"script"`
 	Commands []string `json:"commands"`
 }
+
+type JSONChange struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Field  string `json:"field"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type JSONDiffChain struct {
+	Root    int          `json:"root"`
+	Title   string       `json:"title"`
+	Changes []JSONChange `json:"changes"`
+}
+
+type JSONDiffOutput struct {
+	Since  *time.Time      `json:"since"`
+	Chains []JSONDiffChain `json:"chains"`
+}
+
+type JSONOldestChain struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type JSONAuthorCount struct {
+	Author       string `json:"author"`
+	PullRequests int    `json:"pullRequests"`
+}
+
+type JSONStats struct {
+	Chains          int               `json:"chains"`
+	PullRequests    int               `json:"pullRequests"`
+	AverageDepth    float64           `json:"averageDepth"`
+	OldestChain     *JSONOldestChain  `json:"oldestChain"`
+	Additions       int               `json:"additions"`
+	Deletions       int               `json:"deletions"`
+	BlockedOnCI     int               `json:"blockedOnCI"`
+	BlockedOnReview int               `json:"blockedOnReview"`
+	Authors         []JSONAuthorCount `json:"authors"`
+}
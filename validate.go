@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// problem describes an inconsistency in the branch graph which
+// chainlink had to work around while building the chains.
+type problem struct {
+	kind    string // "cycle", "duplicate-head", "closed-base"
+	prs     []int
+	message string
+}
+
+// validateGraph looks for relationships that can't be represented as a
+// tree: cycles, PRs sharing a head branch and PRs targeting branches of
+// closed PRs. Cycles are broken by making their lowest numbered PR a
+// root so that every traversal of the mappings terminates.
+func validateGraph(d *data, resp Response) {
+	edges := resp.Data.Repository.PullRequests.Edges
+
+	// Duplicate heads, in the order they show up in the response
+	heads := map[string][]int{}
+	headOrder := []string{}
+	for _, p := range edges {
+		head := p.Node.HeadRefName
+		if _, ok := heads[head]; !ok {
+			headOrder = append(headOrder, head)
+		}
+		heads[head] = append(heads[head], p.Node.Number)
+	}
+	for _, head := range headOrder {
+		nums := heads[head]
+		if head == d.defaultBranch {
+			for _, n := range nums {
+				d.problems = append(d.problems, problem{
+					kind:    "duplicate-head",
+					prs:     []int{n},
+					message: fmt.Sprintf("#%d uses the default branch %q as its head", n, head),
+				})
+			}
+			continue
+		}
+
+		if len(nums) < 2 {
+			continue
+		}
+
+		d.problems = append(d.problems, problem{
+			kind: "duplicate-head",
+			prs:  nums,
+			message: fmt.Sprintf(
+				"PRs %s share head branch %q; PRs based on it are shown under #%d",
+				joinPRNumbers(nums), head, d.branch[head]),
+		})
+	}
+
+	// Bases which belong to closed or merged PRs
+	for _, p := range edges {
+		n := p.Node
+		if n.BaseRef == nil || d.branch[n.BaseRefName] != 0 || n.BaseRefName == d.defaultBranch {
+			continue
+		}
+
+		for _, closed := range n.BaseRef.AssociatedPullRequests.Nodes {
+			d.problems = append(d.problems, problem{
+				kind: "closed-base",
+				prs:  []int{n.Number, closed.Number},
+				message: fmt.Sprintf(
+					"#%d targets branch %q of %s PR #%d",
+					n.Number, n.BaseRefName, strings.ToLower(closed.State), closed.Number),
+			})
+		}
+	}
+
+	for _, cycle := range findCycles(d.mappings) {
+		root := slices.Min(cycle)
+		d.problems = append(d.problems, problem{
+			kind: "cycle",
+			prs:  cycle,
+			message: fmt.Sprintf(
+				"PRs %s form a cycle through their base branches; showing #%d as a root",
+				joinPRNumbers(cycle), root),
+		})
+		reroot(d.mappings, root)
+	}
+}
+
+// findCycles returns the PRs forming each cycle in the base pointers of
+// the mappings, ordered along the cycle.
+func findCycles(m map[int]mapping) [][]int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	nums := make([]int, 0, len(m))
+	for n := range m {
+		nums = append(nums, n)
+	}
+	slices.Sort(nums)
+
+	state := map[int]int{}
+	cycles := [][]int{}
+	for _, n := range nums {
+		path := []int{}
+		iter := n
+		for iter != 0 && state[iter] == unvisited {
+			state[iter] = visiting
+			path = append(path, iter)
+			iter = m[iter].base
+		}
+
+		if iter != 0 && state[iter] == visiting {
+			start := slices.Index(path, iter)
+			cycles = append(cycles, slices.Clone(path[start:]))
+		}
+
+		for _, p := range path {
+			state[p] = visited
+		}
+	}
+
+	return cycles
+}
+
+// reroot detaches a PR from its base and moves it to the root.
+func reroot(m map[int]mapping, num int) {
+	old := m[num].base
+	m[old] = mapping{
+		base: m[old].base,
+		following: slices.DeleteFunc(slices.Clone(m[old].following), func(f int) bool {
+			return f == num
+		}),
+	}
+
+	m[num] = mapping{base: 0, following: m[num].following}
+	m[0] = mapping{following: append(slices.Clone(m[0].following), num)}
+}
+
+func joinPRNumbers(nums []int) string {
+	strs := make([]string, 0, len(nums))
+	for _, n := range nums {
+		strs = append(strs, fmt.Sprintf("#%d", n))
+	}
+
+	return strings.Join(strs, ", ")
+}
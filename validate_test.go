@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestFindCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		mp   map[int]mapping
+		want [][]int
+	}{
+		{
+			name: "tree",
+			mp: map[int]mapping{
+				0: {following: []int{1}},
+				1: {base: 0, following: []int{2, 3}},
+				2: {base: 1},
+				3: {base: 1},
+			},
+			want: [][]int{},
+		},
+		{
+			name: "two node cycle",
+			mp: map[int]mapping{
+				1: {base: 2, following: []int{2}},
+				2: {base: 1, following: []int{1}},
+			},
+			want: [][]int{{1, 2}},
+		},
+		{
+			name: "cycle with tail",
+			mp: map[int]mapping{
+				1: {base: 2, following: []int{3}},
+				2: {base: 3, following: []int{1}},
+				3: {base: 1, following: []int{2}},
+				4: {base: 3},
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "self reference",
+			mp: map[int]mapping{
+				5: {base: 5, following: []int{5}},
+			},
+			want: [][]int{{5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCycles(tt.mp)
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByNumber_Cycle(t *testing.T) {
+	d := data{mappings: map[int]mapping{
+		1: {base: 2, following: []int{2}},
+		2: {base: 1, following: []int{1}},
+	}}
+
+	// Must terminate even though the mappings were never validated
+	out := filterByNumber(d, 1)
+	if len(out) != 2 {
+		t.Errorf("expected both PRs, got %v", out)
+	}
+}
+
+func TestValidateGraph(t *testing.T) {
+	t.Setenv("CHAINLINK_TOKEN", "test-token")
+	f := newFakeGitHub(t)
+
+	d, err := getData(context.Background(), githubProvider{url: f.URL}, "test", "broken", false, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kinds := map[string][][]int{}
+	for _, p := range d.problems {
+		kinds[p.kind] = append(kinds[p.kind], p.prs)
+	}
+
+	if !slices.EqualFunc(kinds["cycle"], [][]int{{1, 2}}, slices.Equal) {
+		t.Errorf("unexpected cycles %v", kinds["cycle"])
+	}
+	if !slices.EqualFunc(kinds["duplicate-head"], [][]int{{3, 4}, {7}}, slices.Equal) {
+		t.Errorf("unexpected duplicate heads %v", kinds["duplicate-head"])
+	}
+	if !slices.EqualFunc(kinds["closed-base"], [][]int{{6, 99}}, slices.Equal) {
+		t.Errorf("unexpected closed bases %v", kinds["closed-base"])
+	}
+
+	// The cycle is broken with its lowest PR as the root
+	if d.mappings[1].base != 0 || !slices.Contains(d.mappings[0].following, 1) {
+		t.Errorf("expected #1 to be moved to the root, got %+v", d.mappings[1])
+	}
+	if slices.Contains(d.mappings[2].following, 1) {
+		t.Errorf("expected #1 to be removed from #2's children, got %v", d.mappings[2].following)
+	}
+
+	// A PR using the default branch as head doesn't take over the root
+	if d.mappings[3].base != 0 || d.mappings[7].base != 0 {
+		t.Errorf("expected #3 and #7 at the root, got %+v %+v", d.mappings[3], d.mappings[7])
+	}
+
+	if got := filterChain(d, "cycle-b"); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("unexpected chain %v", got)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// watchOptions configures log --watch
+type watchOptions struct {
+	interval time.Duration
+	bell     bool
+	notify   string // shell command run on changes, gets the summary as $1
+	sort     string
+	groupBy  string
+}
+
+// watchChains redraws the chains in place every interval, re-fetching
+// the data and highlighting PRs that changed since the previous
+// refresh. It only returns on invalid options.
+func watchChains(
+	d data,
+	fetch func() (data, error),
+	all bool,
+	output string,
+	tmpl *template.Template,
+	opts FilterOptions,
+	wopts watchOptions,
+) error {
+	switch output {
+	case "default", "small", "markdown":
+	default:
+		return fmt.Errorf("--watch can't be used with --output %s", output)
+	}
+
+	changes := []prChange{}
+	var fetchErr error
+	for {
+		// Clear the screen and move to the top
+		fmt.Print("\x1b[H\x1b[2J")
+		fmt.Printf("Every %s, last refreshed %s\n\n", wopts.interval, time.Now().Format("15:04:05"))
+		if fetchErr != nil {
+			// Keep watching with the old data, the error is
+			// likely transient
+			fmt.Fprintf(os.Stderr, "unable to refresh: %v\n", fetchErr)
+		}
+
+		mappings := watchedMappings(d, all, opts)
+		mappings = sortMappings(d, mappings, wopts.sort)
+		groups := groupChains(d, mappings, wopts.groupBy, opts)
+
+		printProblems(d.problems)
+		err := printGroups(d, mappings, output, tmpl, groups, changesByPR(changes))
+		if err != nil {
+			return err
+		}
+
+		removed := []string{}
+		for _, c := range changes {
+			if c.field == "removed" {
+				removed = append(removed, c.String())
+			}
+		}
+		if len(removed) > 0 {
+			fmt.Println()
+			fmt.Println(strings.Join(removed, "\n"))
+		}
+
+		if len(changes) > 0 {
+			notifyChanges(changes, wopts)
+		}
+
+		time.Sleep(wopts.interval)
+
+		changes = []prChange{}
+		next, err := fetch()
+		fetchErr = err
+		if err == nil {
+			changes = visibleChanges(d, next, all, opts)
+			d = next
+		}
+	}
+}
+
+// watchedMappings are the chains shown while watching, before sorting
+func watchedMappings(d data, all bool, opts FilterOptions) map[int]mapping {
+	mappings := d.mappings
+	if !all {
+		mappings = filterChains(d.mappings)
+	}
+
+	return filterWholeChains(d, mappings, opts)
+}
+
+// visibleChanges diffs the PRs which are shown before or after the
+// refresh, so that changes to filtered out PRs don't show up or notify.
+// PRs which stopped matching the filters are still reported, along
+// with what changed about them.
+func visibleChanges(prev, next data, all bool, opts FilterOptions) []prChange {
+	visible := map[int]bool{}
+	for _, d := range []data{prev, next} {
+		var walk func(nodes []treeNode)
+		walk = func(nodes []treeNode) {
+			for _, n := range nodes {
+				visible[n.number] = true
+				walk(n.children)
+			}
+		}
+		walk(visibleTree(d, watchedMappings(d, all, opts), 0, opts))
+	}
+
+	changes := []prChange{}
+	for _, c := range diffPRs(prev.prs, next.prs) {
+		if visible[c.number] {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func changesByPR(changes []prChange) map[int][]prChange {
+	byPR := map[int][]prChange{}
+	for _, c := range changes {
+		byPR[c.number] = append(byPR[c.number], c)
+	}
+	return byPR
+}
+
+// annotateChanges marks a line of output with what changed in the PR
+func annotateChanges(line string, changes []prChange) string {
+	if len(changes) == 0 {
+		return line
+	}
+
+	fields := []string{}
+	for _, c := range changes {
+		if c.field == "added" {
+			fields = append(fields, "new")
+		} else {
+			fields = append(fields, fmt.Sprintf("%s %s→%s", c.field, orNone(c.from), orNone(c.to)))
+		}
+	}
+
+	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+	return line + " " + yellow("◀ "+strings.Join(fields, ", "))
+}
+
+func notifyChanges(changes []prChange, wopts watchOptions) {
+	if wopts.bell {
+		fmt.Print("\a")
+	}
+
+	if len(wopts.notify) == 0 {
+		return
+	}
+
+	summary := []string{}
+	for _, c := range changes {
+		summary = append(summary, c.String())
+	}
+
+	err := exec.Command("sh", "-c", wopts.notify, "chainlink", strings.Join(summary, "\n")).Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notify command failed: %v\n", err)
+	}
+}
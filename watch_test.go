@@ -0,0 +1,65 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestAnnotateChanges(t *testing.T) {
+	color.NoColor = true
+
+	if got := annotateChanges("#1 One", nil); got != "#1 One" {
+		t.Errorf("expected unchanged line, got %q", got)
+	}
+
+	got := annotateChanges("#1 One", []prChange{
+		{number: 1, field: "checks", from: "", to: "success"},
+		{number: 1, field: "review", from: "pending", to: "approved"},
+	})
+	if got != "#1 One ◀ checks none→success, review pending→approved" {
+		t.Errorf("unexpected annotation %q", got)
+	}
+}
+
+func TestVisibleChanges(t *testing.T) {
+	prev := tuiTestData()
+
+	next := tuiTestData()
+	next.prs = maps.Clone(next.prs)
+	for _, n := range []int{2, 4} {
+		p := next.prs[n]
+		p.checksState = "success"
+		next.prs[n] = p
+	}
+	// #6 isn't a chain and #5 is filtered out
+	six := next.prs[6]
+	six.checksState = "failure"
+	next.prs[6] = six
+	five := next.prs[5]
+	five.checksState = "failure"
+	next.prs[5] = five
+
+	opts := FilterOptions{Author: "-dave,-carol"}
+	numbers := func(changes []prChange) []int {
+		nums := []int{}
+		for _, c := range changes {
+			nums = append(nums, c.number)
+		}
+		return nums
+	}
+
+	if got := numbers(visibleChanges(prev, next, false, opts)); !slices.Equal(got, []int{2}) {
+		t.Errorf("expected only changes to #2, got %v", got)
+	}
+	if got := numbers(visibleChanges(prev, next, true, FilterOptions{})); !slices.Equal(got, []int{2, 4, 5, 6}) {
+		t.Errorf("expected all changes with --all, got %v", got)
+	}
+
+	// A PR which stops matching is still reported
+	if got := numbers(visibleChanges(prev, next, false, FilterOptions{Checks: "fail"})); !slices.Equal(got, []int{2, 5}) {
+		t.Errorf("expected changes to #2 and #5, got %v", got)
+	}
+}